@@ -1,144 +1,302 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"image/jpeg"
+	"image"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/erdaltsksn/cui"
 	"github.com/fatih/color"
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/konradit/mmt/pkg/videomanipulation"
 	"github.com/nfnt/resize"
 	"github.com/spf13/cobra"
+	"github.com/vbauerster/mpb/v8"
 	"github.com/wayneashleyberry/lut/pkg/cubelut"
 )
 
-func applyLUTToFile(sourceFilename, lutFilename string, intensity float64, quality int, resizeTo string) error {
-	lutFile, err := os.Open(lutFilename)
+type lutOptions struct {
+	LUT       cubelut.CubeFile
+	LUTPath   string
+	Intensity float64
+	Quality   int
+	Resize    string
+	Codec     videomanipulation.OutputCodec
+	Input     string
+	Output    string
+}
+
+// gradeImage runs srcImg through the LUT and resizes it when asked to
+func gradeImage(srcImg image.Image, options lutOptions) (image.Image, error) {
+	img, err := utils.ApplyLUT(options.LUT, srcImg, options.Intensity)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer lutFile.Close()
 
-	sourceFile, err := os.Open(sourceFilename)
+	resolution := regexp.MustCompile(`\d+x\d+`)
+
+	if options.Resize != "" && resolution.MatchString(options.Resize) {
+		width := strings.Split(options.Resize, "x")[0]
+		height := strings.Split(options.Resize, "x")[1]
+
+		parsedWidth, err := strconv.ParseUint(width, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		parsedHeight, err := strconv.ParseUint(height, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		img = resize.Resize(uint(parsedWidth), uint(parsedHeight), img, resize.Lanczos3)
+	}
+	return img, nil
+}
+
+func applyLUTToFile(sourceFilename, destination string, options lutOptions) error {
+	srcImg, format, err := utils.DecodeImage(sourceFilename)
 	if err != nil {
 		return err
 	}
-	defer sourceFile.Close()
-
-	srcImg, err := jpeg.Decode(sourceFile)
+	exifBlob, err := utils.ReadEXIF(sourceFilename)
 	if err != nil {
 		return err
 	}
 
-	lutReader := bufio.NewReader(lutFile)
-
-	cubefile, err := cubelut.Parse(lutReader)
+	img, err := gradeImage(srcImg, options)
 	if err != nil {
 		return err
 	}
 
-	img, err := cubefile.Apply(srcImg, intensity)
+	err = os.MkdirAll(filepath.Dir(destination), 0o755)
 	if err != nil {
 		return err
 	}
+	return utils.EncodeImage(destination, img, format, options.Quality, exifBlob)
+}
 
-	resolution := regexp.MustCompile(`\d+x\d+`)
+func lutOutputFilename(sourceFilename, lutFilename, ext string) string {
+	return filepath.Join(filepath.Dir(sourceFilename),
+		fmt.Sprintf("%s %s%s",
+			strings.Replace(filepath.Base(sourceFilename), filepath.Ext(sourceFilename), "", -1),
+			strings.Replace(filepath.Base(lutFilename), filepath.Ext(lutFilename), "", -1),
+			ext,
+		),
+	)
+}
 
-	if resizeTo != "" && resolution.MatchString(resizeTo) {
-		width := strings.Split(resizeTo, "x")[0]
-		height := strings.Split(resizeTo, "x")[1]
+// lutOutputPath keeps graded files next to the source, or mirrors the folders below input inside output
+func lutOutputPath(sourceFilename, ext string, options lutOptions) string {
+	filename := lutOutputFilename(sourceFilename, options.LUTPath, ext)
+	if options.Output == "" {
+		return filename
+	}
+	root := options.Input
+	if stat, err := os.Stat(root); err == nil && !stat.IsDir() {
+		root = filepath.Dir(root)
+	}
+	relative, err := filepath.Rel(root, filepath.Dir(sourceFilename))
+	if err != nil || strings.HasPrefix(relative, "..") {
+		relative = ""
+	}
+	return filepath.Join(options.Output, relative, filepath.Base(filename))
+}
 
-		parsedWidth, err := strconv.ParseUint(width, 10, 32)
+func isLUTVideo(filename string) bool {
+	ext := strings.ToUpper(filepath.Ext(filename))
+	return ext == ".MP4" || ext == ".MOV"
+}
+
+func isLUTImage(filename string) bool {
+	_, ok := utils.ImageFormat(filename)
+	return ok
+}
+
+// findLUTInputs splits input into images and videos, folders are only descended into with recursive
+func findLUTInputs(input string, recursive bool) ([]string, []string, error) {
+	images, videos := []string{}, []string{}
+	add := func(path string) {
+		switch {
+		case isLUTVideo(path):
+			videos = append(videos, path)
+		case isLUTImage(path):
+			images = append(images, path)
+		}
+	}
+
+	stat, err := os.Stat(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !stat.IsDir() {
+		add(input)
+		return images, videos, nil
+	}
+	if !recursive {
+		files, err := ioutil.ReadDir(input)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
-		parsedHeight, err := strconv.ParseUint(height, 10, 32)
+		for _, file := range files {
+			if !file.IsDir() {
+				add(filepath.Join(input, file.Name()))
+			}
+		}
+		return images, videos, nil
+	}
+	err = filepath.Walk(input, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if !info.IsDir() {
+			add(path)
+		}
+		return nil
+	})
+	return images, videos, err
+}
 
-		img = resize.Resize(uint(parsedWidth), uint(parsedHeight), img, resize.Lanczos3)
+// applyLUTToImages grades images with at most jobs at once
+func applyLUTToImages(images []string, options lutOptions, jobs int) {
+	semaphore := make(chan struct{}, jobs)
+	wg := sync.WaitGroup{}
+	for _, photo := range images {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(photo string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			color.Yellow(">> Applying LUT to: %s...", photo)
+			err := applyLUTToFile(photo, lutOutputPath(photo, filepath.Ext(photo), options), options)
+			if err != nil {
+				color.Red(">> %s: %s", filepath.Base(photo), err.Error())
+				return
+			}
+			color.Green(">> Successfully applied LUT to: %s", photo)
+		}(photo)
 	}
-	destinationFile, err := os.Create(
-		filepath.Join(filepath.Dir(sourceFilename),
-			fmt.Sprintf("%s %s%s",
-				strings.Replace(filepath.Base(sourceFilename), filepath.Ext(sourceFilename), "", -1),
-				strings.Replace(filepath.Base(lutFilename), filepath.Ext(lutFilename), "", -1),
-				filepath.Ext(sourceFilename),
-			),
-		),
-	)
+	wg.Wait()
+}
+
+// videoLUT is a LUT file ffmpeg's lut3d reads, Hald CLUT images are written to a temporary .cube
+func videoLUT(options lutOptions) (string, func(), error) {
+	ext := strings.ToLower(filepath.Ext(options.LUTPath))
+	if ext == ".cube" || ext == ".3dl" {
+		return options.LUTPath, func() {}, nil
+	}
+	f, err := ioutil.TempFile("", "mmt-*.cube")
 	if err != nil {
-		return err
+		return "", nil, err
+	}
+	defer f.Close()
+	cleanup := func() { os.Remove(f.Name()) }
+	if _, err := f.Write(options.LUT.Bytes()); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return f.Name(), cleanup, nil
+}
+
+func applyLUTToVideos(videos []string, options lutOptions) {
+	lut, cleanup, err := videoLUT(options)
+	if err != nil {
+		color.Red(">> %s: %s", filepath.Base(options.LUTPath), err.Error())
+		return
 	}
-	defer destinationFile.Close()
+	defer cleanup()
 
-	return jpeg.Encode(destinationFile, img, &jpeg.Options{
-		Quality: quality,
-	})
+	progressBar := mpb.New(
+		mpb.WithWidth(60),
+		mpb.WithRefreshRate(180*time.Millisecond))
+
+	ffprobe := utils.NewFFprobe(nil)
+	for _, video := range videos {
+		frames, err := ffprobe.Frames(video)
+		if err != nil {
+			color.Red(">> %s: %s", filepath.Base(video), err.Error())
+			continue
+		}
+		output := lutOutputPath(video, options.Codec.Ext, options)
+		if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+			color.Red(">> %s: %s", filepath.Base(video), err.Error())
+			continue
+		}
+		bar := utils.GetNewBar(progressBar, int64(frames.Streams[0].Frames), filepath.Base(output), utils.Percentage)
+		err = videomanipulation.New().ApplyLUT(video, output, lut, options.Intensity, options.Codec, bar)
+		if err != nil {
+			bar.Abort(false)
+			color.Red(">> %s: %s", filepath.Base(video), err.Error())
+			continue
+		}
+		bar.SetTotal(-1, true)
+	}
+	progressBar.Wait()
 }
 
 var applyLutCmd = &cobra.Command{
 	Use:   "apply-lut",
-	Short: "Apply LUT to one or more images",
+	Short: "Apply LUT to one or more images or videos",
 	Run: func(cmd *cobra.Command, args []string) {
-		input := getFlagString(cmd, "input")
-		lutFile := getFlagString(cmd, "lut")
+		options := lutOptions{
+			Input:   getFlagString(cmd, "input"),
+			Output:  getFlagString(cmd, "output"),
+			LUTPath: getFlagString(cmd, "lut"),
+			Quality: getFlagInt(cmd, "quality", "100"),
+			Resize:  getFlagString(cmd, "resize"),
+		}
+		options.Intensity = float64(getFlagInt(cmd, "intensity", "100")) / 100
+		jobs := getFlagInt(cmd, "jobs", strconv.Itoa(runtime.NumCPU()))
+		if jobs < 1 {
+			jobs = 1
+		}
+		recursive, _ := cmd.Flags().GetBool("recursive")
 
-		intensity := getFlagInt(cmd, "intensity", "100")
-		intensityParsed := float64(intensity) / 100
+		codecName := getFlagString(cmd, "codec")
+		if codecName == "" {
+			codecName = "h264"
+		}
+		codec, err := videomanipulation.GetOutputCodec(codecName)
+		if err != nil {
+			cui.Error("Unknown codec, use one of h264, h265, prores", err)
+		}
+		options.Codec = codec
 
-		quality := getFlagInt(cmd, "quality", "100")
-		resizeTo := getFlagString(cmd, "resize")
+		options.LUT, err = utils.ReadLUT(options.LUTPath)
+		if err != nil {
+			cui.Error("Problem reading LUT, use a .cube, .3dl or Hald CLUT .png", err)
+		}
 
-		stat, err := os.Stat(input)
+		images, videos, err := findLUTInputs(options.Input, recursive)
 		if err != nil {
 			cui.Error(err.Error())
 		}
 
-		if stat.IsDir() {
-			files, err := ioutil.ReadDir(input)
-			if err != nil {
-				cui.Error(err.Error())
-			}
-
-			for _, file := range files {
-				actualFilename := filepath.Join(input, file.Name())
-				if filepath.Ext(file.Name()) != ".JPG" {
-					continue
-				}
-				color.Yellow(">> Applying LUT to: %s...", file.Name())
-				err = applyLUTToFile(actualFilename, lutFile, intensityParsed, quality, resizeTo)
-				if err != nil {
-					color.Red(err.Error())
-					continue
-				}
-				color.Green(">> Successfully applied LUT to: %s", file.Name())
-			}
+		if len(images) > 0 {
+			applyLUTToImages(images, options, jobs)
 		}
-
-		if !stat.IsDir() && filepath.Ext(input) == ".JPG" {
-			color.Yellow(">> Applying LUT to: %s...", input)
-			err = applyLUTToFile(input, lutFile, intensityParsed, quality, resizeTo)
-			if err != nil {
-				color.Red(err.Error())
-			}
-			color.Green(">> Successfully applied LUT to: %s", input)
+		if len(videos) > 0 {
+			applyLUTToVideos(videos, options)
 		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(applyLutCmd)
-	applyLutCmd.Flags().StringP("input", "i", "", "JPG File or Directory with JPG files")
-	applyLutCmd.Flags().StringP("lut", "l", "", "Path to LUT file, only .CUBE supported")
+	applyLutCmd.Flags().StringP("input", "i", "", "JPG/PNG/TIFF/MP4/MOV File or Directory with them")
+	applyLutCmd.Flags().StringP("output", "o", "", "Directory for graded files, folders below input are kept (default: next to the source)")
+	applyLutCmd.Flags().StringP("lut", "l", "", "Path to LUT file: .cube, .3dl or Hald CLUT .png")
+	applyLutCmd.Flags().String("codec", "", "Codec for graded videos: h264, h265, prores (default: h264)")
 	applyLutCmd.Flags().String("intensity", "", "Intensity of filter from 1 - 100")
 	applyLutCmd.Flags().String("quality", "", "JPG quality (max + default: 100)")
 	applyLutCmd.Flags().String("resize", "", "Resize image ([width]x[height)")
+	applyLutCmd.Flags().String("jobs", "", "Images graded at once (default: number of CPUs)")
+	applyLutCmd.Flags().BoolP("recursive", "r", false, "Also grade files in subdirectories of input")
 }
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erdaltsksn/cui"
+	"github.com/fatih/color"
+	"github.com/konradit/mmt/pkg/gopro"
+	"github.com/spf13/cobra"
+)
+
+func getCameraControls(cmd *cobra.Command) []*gopro.CameraControl {
+	ctx := context.Background()
+	ips := getFlagSlice(cmd, "ip")
+	if len(ips) == 0 {
+		devices, err := gopro.GetGoProNetworkAddresses(ctx)
+		if err != nil {
+			cui.Error(err.Error())
+		}
+		for _, device := range devices {
+			ips = append(ips, device.IP)
+		}
+	}
+	if len(ips) == 0 {
+		cui.Error("No GoPro cameras found via Connect")
+	}
+
+	controls := []*gopro.CameraControl{}
+	for _, ip := range ips {
+		control, err := gopro.NewCameraControl(ctx, ip)
+		if err != nil {
+			color.Red(">> %s: %s", ip, err.Error())
+			continue
+		}
+		controls = append(controls, control)
+	}
+	return controls
+}
+
+func forEachCamera(cmd *cobra.Command, action string, do func(ctx context.Context, control *gopro.CameraControl) error) {
+	ctx := context.Background()
+	for _, control := range getCameraControls(cmd) {
+		if err := do(ctx, control); err != nil {
+			color.Red(">> %s: %s failed: %s", control.Name(), action, err.Error())
+			continue
+		}
+		color.Green(">> %s: %s", control.Name(), action)
+	}
+}
+
+var cameraCmd = &cobra.Command{
+	Use:   "camera",
+	Short: "Control GoPro cameras connected via Connect (USB Ethernet)",
+}
+
+var cameraStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show battery, storage and recording state",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		for _, control := range getCameraControls(cmd) {
+			status, err := control.Status(ctx)
+			if err != nil {
+				color.Red(">> %s: %s", control.Name(), err.Error())
+				continue
+			}
+			color.Yellow("📹 %s - %s", control.Name(), control.Device.Info.Info.FirmwareVersion)
+			color.White(fmt.Sprintf("\tBattery: %d%%", status.Battery))
+			color.White(fmt.Sprintf("\tRecording: %t", status.Recording))
+			color.White(fmt.Sprintf("\tRemaining space: %.1f GB", float64(status.RemainingSpace)/1024/1024/1024))
+			color.White(fmt.Sprintf("\tVideos: %d, Photos: %d", status.Videos, status.Photos))
+		}
+	},
+}
+
+var cameraShutterCmd = &cobra.Command{
+	Use:       "shutter start|stop",
+	Short:     "Start or stop capturing",
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: []string{"start", "stop"},
+	Run: func(cmd *cobra.Command, args []string) {
+		start := args[0] == "start"
+		forEachCamera(cmd, "shutter "+args[0], func(ctx context.Context, control *gopro.CameraControl) error {
+			return control.Shutter(ctx, start)
+		})
+	},
+}
+
+var cameraPresetCmd = &cobra.Command{
+	Use:       "preset video|photo|timelapse",
+	Short:     "Switch the camera to a preset group",
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: []string{string(gopro.PresetVideo), string(gopro.PresetPhoto), string(gopro.PresetTimelapse)},
+	Run: func(cmd *cobra.Command, args []string) {
+		forEachCamera(cmd, "preset "+args[0], func(ctx context.Context, control *gopro.CameraControl) error {
+			return control.Preset(ctx, args[0])
+		})
+	},
+}
+
+var cameraSetCmd = &cobra.Command{
+	Use:   "set <setting>=<value>...",
+	Short: "Change settings, eg: resolution=4k fps=60 lens=linear or 2=1",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, pair := range args {
+			if _, _, err := gopro.ParseSetting(pair); err != nil {
+				cui.Error("Problem parsing "+pair, err)
+			}
+		}
+		for _, pair := range args {
+			pair := pair
+			forEachCamera(cmd, "set "+pair, func(ctx context.Context, control *gopro.CameraControl) error {
+				return control.Set(ctx, pair)
+			})
+		}
+	},
+}
+
+var cameraDateTimeCmd = &cobra.Command{
+	Use:       "datetime sync",
+	Short:     "Set the camera clock to the host time",
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: []string{"sync"},
+	Run: func(cmd *cobra.Command, args []string) {
+		forEachCamera(cmd, "datetime sync", func(ctx context.Context, control *gopro.CameraControl) error {
+			return control.SyncDateTime(ctx, time.Now())
+		})
+	},
+}
+
+var cameraSleepCmd = &cobra.Command{
+	Use:   "sleep",
+	Short: "Put the camera to sleep",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		forEachCamera(cmd, "sleep", func(ctx context.Context, control *gopro.CameraControl) error {
+			return control.Sleep(ctx)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cameraCmd)
+	cameraCmd.PersistentFlags().StringSlice("ip", []string{}, "Camera IP addresses, do not specify to use every camera detected")
+	cameraCmd.AddCommand(cameraStatusCmd)
+	cameraCmd.AddCommand(cameraShutterCmd)
+	cameraCmd.AddCommand(cameraPresetCmd)
+	cameraCmd.AddCommand(cameraSetCmd)
+	cameraCmd.AddCommand(cameraDateTimeCmd)
+	cameraCmd.AddCommand(cameraSleepCmd)
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/erdaltsksn/cui"
+	"github.com/fatih/color"
+	"github.com/konradit/mmt/pkg/gopro"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// getCameraMedia lists media on the camera, verifying it against --output when given
+func getCameraMedia(ctx context.Context, cmd *cobra.Command, control *gopro.CameraControl) ([]gopro.CameraMedia, bool, error) {
+	media, err := control.Media(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	output := getFlagString(cmd, "output")
+	if output == "" {
+		return media, false, nil
+	}
+	return media, true, control.VerifyImported(media, output)
+}
+
+// deleteMedia removes files from the camera, refusing anything without a verified copy unless --force is set
+func deleteMedia(ctx context.Context, cmd *cobra.Command, control *gopro.CameraControl, media []gopro.CameraMedia, verified bool) {
+	force, _ := cmd.Flags().GetBool("force")
+	deleted := 0
+	for _, m := range media {
+		if !force && m.Imported == "" {
+			if verified {
+				color.Yellow(">> %s: skipping %s, no verified copy in output", control.Name(), m.Path())
+			}
+			continue
+		}
+		if err := control.Delete(ctx, m); err != nil {
+			color.Red(">> %s: deleting %s failed: %s", control.Name(), m.Path(), err.Error())
+			continue
+		}
+		deleted++
+	}
+	color.Green(">> %s: deleted %d files", control.Name(), deleted)
+}
+
+func requireVerification(cmd *cobra.Command) {
+	force, _ := cmd.Flags().GetBool("force")
+	if !force && getFlagString(cmd, "output") == "" {
+		cui.Error("Pass --output to check files were imported before deleting them, or --force to skip the check")
+	}
+}
+
+var cameraMediaCmd = &cobra.Command{
+	Use:   "media",
+	Short: "List, delete and tag media stored on the camera",
+}
+
+var cameraMediaLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List media on the camera, with --output also shows which files were imported",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		for _, control := range getCameraControls(cmd) {
+			media, verified, err := getCameraMedia(ctx, cmd, control)
+			if err != nil {
+				color.Red(">> %s: %s", control.Name(), err.Error())
+				continue
+			}
+			color.Yellow("📹 %s", control.Name())
+			header := []string{"File", "Size (MB)", "Created"}
+			if verified {
+				header = append(header, "Imported")
+			}
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader(header)
+			for _, m := range media {
+				row := []string{m.Path(), fmt.Sprintf("%.1f", float64(m.Size)/1024/1024), m.Created.Format("02-01-2006 15:04")}
+				if verified {
+					row = append(row, strconv.FormatBool(m.Imported != ""))
+				}
+				table.Append(row)
+			}
+			table.Render() // Send output
+		}
+	},
+}
+
+var cameraMediaRmCmd = &cobra.Command{
+	Use:   "rm <folder/file>...",
+	Short: "Delete files from the camera once they have been imported",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireVerification(cmd)
+		ctx := context.Background()
+		for _, control := range getCameraControls(cmd) {
+			media, verified, err := getCameraMedia(ctx, cmd, control)
+			if err != nil {
+				color.Red(">> %s: %s", control.Name(), err.Error())
+				continue
+			}
+			selected := []gopro.CameraMedia{}
+			for _, name := range args {
+				m, found := gopro.FindMedia(media, name)
+				if !found {
+					color.Red(">> %s: %s not found", control.Name(), name)
+					continue
+				}
+				selected = append(selected, m)
+			}
+			deleteMedia(ctx, cmd, control, selected, verified)
+		}
+	},
+}
+
+var cameraMediaRmAllCmd = &cobra.Command{
+	Use:   "rm-all",
+	Short: "Delete every file from the camera, with --only-imported just the ones with a verified copy",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		onlyImported, _ := cmd.Flags().GetBool("only-imported")
+		force, _ := cmd.Flags().GetBool("force")
+		if onlyImported && getFlagString(cmd, "output") == "" {
+			cui.Error("--only-imported needs --output to check files against")
+		}
+		if !onlyImported && !force {
+			cui.Error("Pass --only-imported --output <dir> to keep files not yet imported, or --force to wipe the camera")
+		}
+
+		ctx := context.Background()
+		for _, control := range getCameraControls(cmd) {
+			if !onlyImported {
+				if err := control.DeleteAll(ctx); err != nil {
+					color.Red(">> %s: %s", control.Name(), err.Error())
+					continue
+				}
+				color.Green(">> %s: deleted all files", control.Name())
+				continue
+			}
+			media, verified, err := getCameraMedia(ctx, cmd, control)
+			if err != nil {
+				color.Red(">> %s: %s", control.Name(), err.Error())
+				continue
+			}
+			deleteMedia(ctx, cmd, control, media, verified)
+		}
+	},
+}
+
+var cameraMediaHiLightCmd = &cobra.Command{
+	Use:   "hilight <folder/file> <seconds>",
+	Short: "Add a HiLight tag to a video on the camera",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		seconds, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			cui.Error("Problem parsing "+args[1], err)
+		}
+		ctx := context.Background()
+		for _, control := range getCameraControls(cmd) {
+			media, err := control.Media(ctx)
+			if err != nil {
+				color.Red(">> %s: %s", control.Name(), err.Error())
+				continue
+			}
+			m, found := gopro.FindMedia(media, args[0])
+			if !found {
+				color.Red(">> %s: %s not found", control.Name(), args[0])
+				continue
+			}
+			if err := control.HiLight(ctx, m, int(seconds*1000)); err != nil {
+				color.Red(">> %s: %s", control.Name(), err.Error())
+				continue
+			}
+			color.Green(">> %s: tagged %s at %ss", control.Name(), m.Path(), args[1])
+		}
+	},
+}
+
+func init() {
+	cameraCmd.AddCommand(cameraMediaCmd)
+	cameraMediaCmd.PersistentFlags().StringP("output", "o", "", "Folder media was imported to, used to verify files before deleting them")
+	cameraMediaCmd.PersistentFlags().Bool("force", false, "Delete without checking files were imported")
+	cameraMediaRmAllCmd.Flags().Bool("only-imported", false, "Only delete files with a verified copy in --output")
+	cameraMediaCmd.AddCommand(cameraMediaLsCmd)
+	cameraMediaCmd.AddCommand(cameraMediaRmCmd)
+	cameraMediaCmd.AddCommand(cameraMediaRmAllCmd)
+	cameraMediaCmd.AddCommand(cameraMediaHiLightCmd)
+}
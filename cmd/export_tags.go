@@ -5,25 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/erdaltsksn/cui"
 	"github.com/fatih/color"
+	mErrors "github.com/konradit/mmt/pkg/errors"
 	"github.com/konradit/mmt/pkg/gopro"
+	"github.com/konradit/mmt/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
-func tagAsDuration(tag int, increase bool) string {
-	seconds := tag / int(time.Microsecond)
-	if increase {
-		seconds++
-	}
-	return fmt.Sprintf("01:00:%d:%03d", seconds, 0)
-}
-
 func exportCSV(tags gopro.HiLights, output string) error {
 	csvFile, err := os.Create(output)
 	if err != nil {
@@ -51,40 +45,161 @@ func exportJSON(tags gopro.HiLights, output string) error {
 	return os.WriteFile(output, b, 0o600)
 }
 
-func exportEDL(name string, tags gopro.HiLights, output string) error {
-	content := `TITLE: Timeline 1
-FCM: NON-DROP FRAME
-`
-	for index, tag := range tags.Timestamps {
-		content = fmt.Sprintf("%s\n%03d  AX       V     C        %s %s %s %s\n* FROM CLIP NAME: %s\n", content, index, "00:00:00:00", "00:00:00:01", tagAsDuration(tag, false), tagAsDuration(tag, true), name)
+type tagClip struct {
+	Path     string
+	Offset   float64
+	Duration float64
+	Tags     []int
+}
+
+// tagTimeline lays out one recording, every chapter placed after the previous one
+type tagTimeline struct {
+	Name          string
+	FrameRate     float64
+	Width, Height int
+	Clips         []tagClip
+}
+
+func (t tagTimeline) Duration() float64 {
+	total := 0.0
+	for _, clip := range t.Clips {
+		total += clip.Duration
 	}
-	return os.WriteFile(output, []byte(content), 0o600)
+	return total
 }
 
-func extractIndividual(input, output, format string) (int, error) {
-	hilights, err := gopro.GetHiLights(input)
+// Markers returns the position of every tag in seconds from the start of the timeline
+func (t tagTimeline) Markers() []float64 {
+	markers := []float64{}
+	for _, clip := range t.Clips {
+		for _, tag := range clip.Tags {
+			markers = append(markers, clip.Offset+float64(tag)/1000)
+		}
+	}
+	return markers
+}
+
+func (t tagTimeline) HiLights() gopro.HiLights {
+	timestamps := []int{}
+	for _, marker := range t.Markers() {
+		timestamps = append(timestamps, int(math.Round(marker*1000)))
+	}
+	return gopro.HiLights{
+		Count:      len(timestamps),
+		Timestamps: timestamps,
+	}
+}
+
+// buildTimeline lays out the chapters of a recording, their frame rate and size probed only when ffprobe is given
+func buildTimeline(ffprobe *utils.FFprobe, name string, chapters []string) (*tagTimeline, error) {
+	timeline := &tagTimeline{Name: name}
+	offset := 0.0
+	for _, chapter := range chapters {
+		hilights, err := gopro.GetHiLights(chapter)
+		if err != nil {
+			return nil, err
+		}
+		duration, err := chapterDuration(ffprobe, chapter)
+		if err != nil {
+			return nil, err
+		}
+		if ffprobe != nil && timeline.FrameRate == 0 {
+			sizeResp, err := ffprobe.VideoSize(chapter)
+			if err != nil {
+				return nil, err
+			}
+			timeline.FrameRate, err = utils.ParseFrameRate(sizeResp.Streams[0].RFrameRate)
+			if err != nil {
+				return nil, err
+			}
+			timeline.Width = sizeResp.Streams[0].Width
+			timeline.Height = sizeResp.Streams[0].Height
+		}
+		timeline.Clips = append(timeline.Clips, tagClip{
+			Path:     chapter,
+			Offset:   offset,
+			Duration: duration,
+			Tags:     hilights.Timestamps,
+		})
+		offset += duration
+	}
+	return timeline, nil
+}
+
+func chapterDuration(ffprobe *utils.FFprobe, chapter string) (float64, error) {
+	if ffprobe == nil {
+		return gopro.GetDuration(chapter)
+	}
+	durationResp, err := ffprobe.Duration(chapter)
 	if err != nil {
 		return 0, err
 	}
+	return float64(durationResp.Streams[0].Duration), nil
+}
+
+var tagFormatExtensions = map[string]string{
+	"csv":     ".csv",
+	"json":    ".json",
+	"edl":     ".edl",
+	"fcpxml":  ".fcpxml",
+	"xmeml":   ".xml",
+	"youtube": ".txt",
+	"vtt":     ".vtt",
+	"srt":     ".srt",
+}
+
+// probedFormats are laid out in frames, the frame rate and size of the video read with ffprobe
+var probedFormats = map[string]bool{
+	"edl":    true,
+	"fcpxml": true,
+	"xmeml":  true,
+}
 
+func exportTimeline(timeline tagTimeline, output, format string) (int, error) {
+	var err error
 	switch format {
 	case "csv":
-		if output == "" {
-			output = strings.Replace(input, filepath.Ext(input), ".csv", -1)
-		}
-		err = exportCSV(*hilights, output)
+		err = exportCSV(timeline.HiLights(), output)
 	case "json":
-		if output == "" {
-			output = strings.Replace(input, filepath.Ext(input), ".json", -1)
-		}
-		err = exportJSON(*hilights, output)
+		err = exportJSON(timeline.HiLights(), output)
 	case "edl":
-		if output == "" {
-			output = strings.Replace(input, filepath.Ext(input), ".edl", -1)
-		}
-		err = exportEDL(filepath.Base(input), *hilights, output)
+		err = exportEDL(timeline, output)
+	case "fcpxml":
+		err = exportFCPXML(timeline, output)
+	case "xmeml":
+		err = exportXMEML(timeline, output)
+	case "youtube":
+		err = exportYouTubeChapters(timeline, output)
+	case "vtt":
+		err = exportWebVTT(timeline, output)
+	case "srt":
+		err = exportSRT(timeline, output)
+	default:
+		err = mErrors.ErrInvalidSuppliedData(format)
+	}
+	return len(timeline.Markers()), err
+}
+
+func extractIndividual(ffprobe *utils.FFprobe, input, output, format string) (int, error) {
+	timeline, err := buildTimeline(ffprobe, strings.TrimSuffix(filepath.Base(input), filepath.Ext(input)), []string{input})
+	if err != nil {
+		return 0, err
 	}
-	return hilights.Count, err
+	if output == "" {
+		output = strings.TrimSuffix(input, filepath.Ext(input)) + tagFormatExtensions[format]
+	}
+	return exportTimeline(*timeline, output, format)
+}
+
+func extractRecording(ffprobe *utils.FFprobe, name string, chapters []string, outputDir, format string) (int, error) {
+	timeline, err := buildTimeline(ffprobe, name, chapters)
+	if err != nil {
+		return 0, err
+	}
+	if outputDir == "" {
+		outputDir = filepath.Dir(chapters[0])
+	}
+	return exportTimeline(*timeline, filepath.Join(outputDir, name+tagFormatExtensions[format]), format)
 }
 
 var exportTags = &cobra.Command{
@@ -100,24 +215,38 @@ var exportTags = &cobra.Command{
 			cui.Error(err.Error())
 		}
 
+		var ffprobe *utils.FFprobe
+		if probedFormats[format] {
+			probe := utils.NewFFprobe(nil)
+			ffprobe = &probe
+		}
 		if stat.IsDir() {
 			files, err := ioutil.ReadDir(input)
 			if err != nil {
 				cui.Error(err.Error())
 			}
 
+			videos := []string{}
 			for _, file := range files {
-				actualFilename := filepath.Join(input, file.Name())
-				count, err := extractIndividual(actualFilename, output, format)
+				if !file.IsDir() && strings.EqualFold(filepath.Ext(file.Name()), ".MP4") {
+					videos = append(videos, filepath.Join(input, file.Name()))
+				}
+			}
+
+			// Chaptered recordings are exported as a single timeline
+			groups, keys := gopro.GroupChapters(videos)
+			for _, key := range keys {
+				name := strings.TrimSuffix(filepath.Base(key), filepath.Ext(key))
+				count, err := extractRecording(ffprobe, name, groups[key], output, format)
 				if err != nil {
 					cui.Error(err.Error())
 				}
-				color.Green(">> Successfully extracted %d tags", count)
+				color.Green(">> Successfully extracted %d tags from %s", count, name)
 			}
 		}
 
 		if !stat.IsDir() && filepath.Ext(input) == ".MP4" {
-			count, err := extractIndividual(input, output, format)
+			count, err := extractIndividual(ffprobe, input, output, format)
 			if err != nil {
 				cui.Error(err.Error())
 			}
@@ -129,8 +258,8 @@ var exportTags = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(exportTags)
 	exportTags.Flags().StringP("input", "i", "", "MP4 File or directory with MP4 files")
-	exportTags.Flags().StringP("format", "f", "", "formats supported: edl/json/csv")
-	exportTags.Flags().StringP("output", "o", "", "Output file, do not specify to use the input file as reference")
+	exportTags.Flags().StringP("format", "f", "", "formats supported: edl/fcpxml/xmeml/youtube/vtt/srt/json/csv")
+	exportTags.Flags().StringP("output", "o", "", "Output file (or directory when the input is a directory), do not specify to use the input file as reference")
 
 	_ = exportTags.MarkFlagRequired("format")
 	_ = exportTags.MarkFlagRequired("input")
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/konradit/mmt/pkg/utils"
+)
+
+// Resolve starts timelines at 01:00:00:00
+const timelineStartHours = 1
+
+func markerName(index int) string {
+	return fmt.Sprintf("HiLight %d", index+1)
+}
+
+func fileURL(path string) string {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		absolute = path
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(absolute)}).String()
+}
+
+func exportEDL(timeline tagTimeline, output string) error {
+	content := fmt.Sprintf("TITLE: %s\nFCM: NON-DROP FRAME\n\n", timeline.Name)
+	for index, marker := range timeline.Markers() {
+		frame := timelineStartHours*3600*utils.NominalFrameRate(timeline.FrameRate) + utils.SecondsToFrames(marker, timeline.FrameRate)
+		in := utils.FramesToTimecode(frame, timeline.FrameRate)
+		out := utils.FramesToTimecode(frame+1, timeline.FrameRate)
+		content += fmt.Sprintf("%03d  001      V     C        %s %s %s %s  \n |C:ResolveColorBlue |M:%s |D:1\n\n", index+1, in, out, in, out, markerName(index))
+	}
+	return os.WriteFile(output, []byte(content), 0o600)
+}
+
+type chapter struct {
+	Start, End float64
+	Title      string
+}
+
+func getChapters(timeline tagTimeline, minLength float64) []chapter {
+	chapters := []chapter{{Start: 0, Title: "Start"}}
+	for index, marker := range timeline.Markers() {
+		previous := chapters[len(chapters)-1].Start
+		if marker <= previous || marker-previous < minLength {
+			continue
+		}
+		chapters = append(chapters, chapter{Start: marker, Title: markerName(index)})
+	}
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		} else {
+			chapters[i].End = timeline.Duration()
+		}
+	}
+	return chapters
+}
+
+func exportYouTubeChapters(timeline tagTimeline, output string) error {
+	// YouTube ignores chapters shorter than 10 seconds
+	lines := []string{}
+	for _, item := range getChapters(timeline, 10) {
+		lines = append(lines, fmt.Sprintf("%s %s", utils.ChapterTime(item.Start), item.Title))
+	}
+	return os.WriteFile(output, []byte(strings.Join(lines, "\n")+"\n"), 0o600)
+}
+
+func exportWebVTT(timeline tagTimeline, output string) error {
+	content := "WEBVTT\n\n"
+	for index, item := range getChapters(timeline, 0) {
+		content += fmt.Sprintf("%d\n%s --> %s\n%s\n\n", index+1, utils.WebVTTTime(item.Start), utils.WebVTTTime(item.End), item.Title)
+	}
+	return os.WriteFile(output, []byte(content), 0o600)
+}
+
+func exportSRT(timeline tagTimeline, output string) error {
+	content := ""
+	for index, item := range getChapters(timeline, 0) {
+		content += fmt.Sprintf("%d\n%s --> %s\n%s\n\n", index+1, utils.SubRipTime(item.Start), utils.SubRipTime(item.End), item.Title)
+	}
+	return os.WriteFile(output, []byte(content), 0o600)
+}
+
+func writeXML(output, doctype string, document interface{}) error {
+	b, err := xml.MarshalIndent(document, "", "\t")
+	if err != nil {
+		return err
+	}
+	content := xml.Header + doctype + "\n" + string(b) + "\n"
+	return os.WriteFile(output, []byte(content), 0o600)
+}
+
+/* Final Cut Pro X */
+
+type fcpxml struct {
+	XMLName xml.Name     `xml:"fcpxml"`
+	Version string       `xml:"version,attr"`
+	Formats []fcpxFormat `xml:"resources>format"`
+	Assets  []fcpxAsset  `xml:"resources>asset"`
+	Event   fcpxEvent    `xml:"library>event"`
+}
+
+type fcpxEvent struct {
+	Name    string      `xml:"name,attr"`
+	Project fcpxProject `xml:"project"`
+}
+
+type fcpxFormat struct {
+	ID            string `xml:"id,attr"`
+	FrameDuration string `xml:"frameDuration,attr"`
+	Width         int    `xml:"width,attr"`
+	Height        int    `xml:"height,attr"`
+}
+
+type fcpxAsset struct {
+	ID       string `xml:"id,attr"`
+	Name     string `xml:"name,attr"`
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+	HasVideo int    `xml:"hasVideo,attr"`
+	HasAudio int    `xml:"hasAudio,attr"`
+	Format   string `xml:"format,attr"`
+	MediaRep struct {
+		Kind string `xml:"kind,attr"`
+		Src  string `xml:"src,attr"`
+	} `xml:"media-rep"`
+}
+
+type fcpxProject struct {
+	Name     string       `xml:"name,attr"`
+	Sequence fcpxSequence `xml:"sequence"`
+}
+
+type fcpxSequence struct {
+	Format   string    `xml:"format,attr"`
+	Duration string    `xml:"duration,attr"`
+	TCStart  string    `xml:"tcStart,attr"`
+	TCFormat string    `xml:"tcFormat,attr"`
+	Spine    fcpxSpine `xml:"spine"`
+}
+
+type fcpxSpine struct {
+	AssetClips []fcpxAssetClip `xml:"asset-clip"`
+}
+
+type fcpxAssetClip struct {
+	Ref      string       `xml:"ref,attr"`
+	Offset   string       `xml:"offset,attr"`
+	Name     string       `xml:"name,attr"`
+	Duration string       `xml:"duration,attr"`
+	Format   string       `xml:"format,attr"`
+	Markers  []fcpxMarker `xml:"marker"`
+}
+
+type fcpxMarker struct {
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+	Value    string `xml:"value,attr"`
+}
+
+// fcpxTime expresses a frame count as the rational time FCPX expects, eg: 1001/30000s per frame at 29.97
+func fcpxTime(frames int, fps float64) string {
+	if frames == 0 {
+		return "0s"
+	}
+	nominal := utils.NominalFrameRate(fps)
+	if utils.IsNTSC(fps) {
+		return fmt.Sprintf("%d/%ds", frames*1001, nominal*1000)
+	}
+	return fmt.Sprintf("%d/%ds", frames*100, nominal*100)
+}
+
+func exportFCPXML(timeline tagTimeline, output string) error {
+	fps := timeline.FrameRate
+	document := fcpxml{
+		Version: "1.9",
+		Formats: []fcpxFormat{{
+			ID:            "r1",
+			FrameDuration: fcpxTime(1, fps),
+			Width:         timeline.Width,
+			Height:        timeline.Height,
+		}},
+		Event: fcpxEvent{
+			Name: "mmt",
+			Project: fcpxProject{
+				Name: timeline.Name,
+				Sequence: fcpxSequence{
+					Format:   "r1",
+					Duration: fcpxTime(utils.SecondsToFrames(timeline.Duration(), fps), fps),
+					TCStart:  "0s",
+					TCFormat: "NDF",
+				},
+			},
+		},
+	}
+
+	markerIndex := 0
+	for index, clip := range timeline.Clips {
+		id := fmt.Sprintf("r%d", index+2)
+		duration := fcpxTime(utils.SecondsToFrames(clip.Duration, fps), fps)
+		asset := fcpxAsset{
+			ID:       id,
+			Name:     filepath.Base(clip.Path),
+			Start:    "0s",
+			Duration: duration,
+			HasVideo: 1,
+			HasAudio: 1,
+			Format:   "r1",
+		}
+		asset.MediaRep.Kind = "original-media"
+		asset.MediaRep.Src = fileURL(clip.Path)
+		document.Assets = append(document.Assets, asset)
+
+		assetClip := fcpxAssetClip{
+			Ref:      id,
+			Offset:   fcpxTime(utils.SecondsToFrames(clip.Offset, fps), fps),
+			Name:     filepath.Base(clip.Path),
+			Duration: duration,
+			Format:   "r1",
+		}
+		for _, tag := range clip.Tags {
+			assetClip.Markers = append(assetClip.Markers, fcpxMarker{
+				Start:    fcpxTime(utils.SecondsToFrames(float64(tag)/1000, fps), fps),
+				Duration: fcpxTime(1, fps),
+				Value:    markerName(markerIndex),
+			})
+			markerIndex++
+		}
+		document.Event.Project.Sequence.Spine.AssetClips = append(document.Event.Project.Sequence.Spine.AssetClips, assetClip)
+	}
+	return writeXML(output, "<!DOCTYPE fcpxml>", document)
+}
+
+/* Premiere Pro (Final Cut Pro 7 XML) */
+
+type xmemlRate struct {
+	Timebase int    `xml:"timebase"`
+	NTSC     string `xml:"ntsc"`
+}
+
+type xmemlMarker struct {
+	Name    string `xml:"name"`
+	Comment string `xml:"comment"`
+	In      int    `xml:"in"`
+	Out     int    `xml:"out"`
+}
+
+type xmemlFile struct {
+	ID       string    `xml:"id,attr"`
+	Name     string    `xml:"name"`
+	PathURL  string    `xml:"pathurl"`
+	Rate     xmemlRate `xml:"rate"`
+	Duration int       `xml:"duration"`
+}
+
+type xmemlClipItem struct {
+	ID       string        `xml:"id,attr"`
+	Name     string        `xml:"name"`
+	Duration int           `xml:"duration"`
+	Rate     xmemlRate     `xml:"rate"`
+	Start    int           `xml:"start"`
+	End      int           `xml:"end"`
+	In       int           `xml:"in"`
+	Out      int           `xml:"out"`
+	File     xmemlFile     `xml:"file"`
+	Markers  []xmemlMarker `xml:"marker"`
+}
+
+type xmeml struct {
+	XMLName  xml.Name `xml:"xmeml"`
+	Version  string   `xml:"version,attr"`
+	Sequence struct {
+		Name      string          `xml:"name"`
+		Duration  int             `xml:"duration"`
+		Rate      xmemlRate       `xml:"rate"`
+		ClipItems []xmemlClipItem `xml:"media>video>track>clipitem"`
+		Markers   []xmemlMarker   `xml:"marker"`
+	} `xml:"sequence"`
+}
+
+func exportXMEML(timeline tagTimeline, output string) error {
+	fps := timeline.FrameRate
+	rate := xmemlRate{Timebase: utils.NominalFrameRate(fps), NTSC: "FALSE"}
+	if utils.IsNTSC(fps) {
+		rate.NTSC = "TRUE"
+	}
+
+	document := xmeml{Version: "4"}
+	document.Sequence.Name = timeline.Name
+	document.Sequence.Duration = utils.SecondsToFrames(timeline.Duration(), fps)
+	document.Sequence.Rate = rate
+
+	markerIndex := 0
+	for index, clip := range timeline.Clips {
+		start := utils.SecondsToFrames(clip.Offset, fps)
+		duration := utils.SecondsToFrames(clip.Duration, fps)
+		clipItem := xmemlClipItem{
+			ID:       fmt.Sprintf("clipitem-%d", index+1),
+			Name:     filepath.Base(clip.Path),
+			Duration: duration,
+			Rate:     rate,
+			Start:    start,
+			End:      start + duration,
+			In:       0,
+			Out:      duration,
+			File: xmemlFile{
+				ID:       fmt.Sprintf("file-%d", index+1),
+				Name:     filepath.Base(clip.Path),
+				PathURL:  fileURL(clip.Path),
+				Rate:     rate,
+				Duration: duration,
+			},
+		}
+		for _, tag := range clip.Tags {
+			frame := utils.SecondsToFrames(float64(tag)/1000, fps)
+			clipItem.Markers = append(clipItem.Markers, xmemlMarker{Name: markerName(markerIndex), In: frame, Out: -1})
+			document.Sequence.Markers = append(document.Sequence.Markers, xmemlMarker{Name: markerName(markerIndex), In: start + frame, Out: -1})
+			markerIndex++
+		}
+		document.Sequence.ClipItems = append(document.Sequence.ClipItems, clipItem)
+	}
+	return writeXML(output, "<!DOCTYPE xmeml>", document)
+}
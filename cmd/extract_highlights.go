@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/erdaltsksn/cui"
+	"github.com/fatih/color"
+	"github.com/konradit/mmt/pkg/gopro"
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/konradit/mmt/pkg/videomanipulation"
+	"github.com/spf13/cobra"
+	"github.com/vbauerster/mpb/v8"
+)
+
+type highlightCut struct {
+	Source, Output  string
+	Start, Duration float64
+	Reencode        bool
+	Frames          int64
+}
+
+func keyframeBefore(keyframes []float64, at float64) float64 {
+	found := 0.0
+	for _, keyframe := range keyframes {
+		if keyframe > at {
+			break
+		}
+		found = keyframe
+	}
+	return found
+}
+
+func planHighlightCuts(ffprobe *utils.FFprobe, input, output string, before, after, tolerance float64, reencode bool) ([]highlightCut, error) {
+	hilights, err := gopro.GetHiLights(input)
+	if err != nil {
+		return nil, err
+	}
+	if hilights.Count == 0 {
+		return nil, nil
+	}
+
+	durationResp, err := ffprobe.Duration(input)
+	if err != nil {
+		return nil, err
+	}
+	sizeResp, err := ffprobe.VideoSize(input)
+	if err != nil {
+		return nil, err
+	}
+	framerate, err := utils.ParseFrameRate(sizeResp.Streams[0].RFrameRate)
+	if err != nil {
+		return nil, err
+	}
+	keyframes, err := ffprobe.KeyFrames(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if output == "" {
+		output = filepath.Dir(input)
+	}
+	base := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+
+	cuts := []highlightCut{}
+	for _, clip := range gopro.GetHiLightClips(hilights.Timestamps, before, after, float64(durationResp.Streams[0].Duration)) {
+		cut := highlightCut{
+			Source:   input,
+			Output:   filepath.Join(output, fmt.Sprintf("%s-HL%02d%s", base, clip.Index, filepath.Ext(input))),
+			Start:    clip.Start,
+			Duration: clip.Duration,
+			Reencode: reencode,
+		}
+		// Stream copy can only start on a keyframe, if the closest one is too far away re-encode instead
+		keyframe := keyframeBefore(keyframes, clip.Start)
+		if !cut.Reencode && clip.Start-keyframe <= tolerance {
+			cut.Duration += clip.Start - keyframe
+			cut.Start = keyframe
+		} else {
+			cut.Reencode = true
+		}
+		cut.Frames = int64(math.Ceil(cut.Duration * framerate))
+		cuts = append(cuts, cut)
+	}
+	return cuts, nil
+}
+
+var extractHighlightsCmd = &cobra.Command{
+	Use:   "extract-highlights",
+	Short: "Cut clips around HiLight tags in GoPro videos",
+	Run: func(cmd *cobra.Command, args []string) {
+		input := getFlagString(cmd, "input")
+		output := getFlagString(cmd, "output")
+		before := getFlagFloat(cmd, "before", "5")
+		after := getFlagFloat(cmd, "after", "3")
+		tolerance := getFlagFloat(cmd, "keyframe-tolerance", "1")
+		reencode := getFlagBool(cmd, "reencode", "false")
+		reel := getFlagString(cmd, "reel")
+
+		stat, err := os.Stat(input)
+		if err != nil {
+			cui.Error(err.Error())
+		}
+
+		videos := []string{input}
+		if stat.IsDir() {
+			videos = []string{}
+			files, err := ioutil.ReadDir(input)
+			if err != nil {
+				cui.Error(err.Error())
+			}
+			for _, file := range files {
+				if !file.IsDir() && strings.EqualFold(filepath.Ext(file.Name()), ".MP4") {
+					videos = append(videos, filepath.Join(input, file.Name()))
+				}
+			}
+		}
+
+		if output != "" {
+			if _, err := os.Stat(output); os.IsNotExist(err) {
+				err = os.MkdirAll(output, 0o755)
+				if err != nil {
+					cui.Error(err.Error())
+				}
+			}
+		}
+
+		ffprobe := utils.NewFFprobe(nil)
+		cuts := []highlightCut{}
+		for _, video := range videos {
+			videoCuts, err := planHighlightCuts(&ffprobe, video, output, before, after, tolerance, reencode)
+			if err != nil {
+				color.Red(">> %s: %s", filepath.Base(video), err.Error())
+				continue
+			}
+			if len(videoCuts) == 0 {
+				color.Yellow(">> No HiLight tags in %s", filepath.Base(video))
+				continue
+			}
+			cuts = append(cuts, videoCuts...)
+		}
+
+		// Concatenating needs every clip encoded the same way
+		if reel != "" {
+			for _, cut := range cuts {
+				reencode = reencode || cut.Reencode
+			}
+			for i := range cuts {
+				cuts[i].Reencode = reencode
+			}
+		}
+
+		progressBar := mpb.New(
+			mpb.WithWidth(60),
+			mpb.WithRefreshRate(180*time.Millisecond))
+
+		totalFrames := int64(0)
+		clips := []string{}
+		for _, cut := range cuts {
+			bar := utils.GetNewBar(progressBar, cut.Frames, filepath.Base(cut.Output), utils.Percentage)
+			err := videomanipulation.New().Cut(cut.Source, cut.Output, cut.Start, cut.Duration, cut.Reencode, bar)
+			if err != nil {
+				bar.Abort(false)
+				color.Red(">> %s: %s", filepath.Base(cut.Output), err.Error())
+				continue
+			}
+			bar.SetTotal(-1, true)
+			totalFrames += cut.Frames
+			clips = append(clips, cut.Output)
+		}
+
+		if reel != "" && len(clips) > 0 {
+			bar := utils.GetNewBar(progressBar, totalFrames, filepath.Base(reel), utils.Percentage)
+			err := videomanipulation.New().MergeTo(reel, bar, clips...)
+			if err != nil {
+				bar.Abort(false)
+				color.Red(">> %s: %s", filepath.Base(reel), err.Error())
+			} else {
+				bar.SetTotal(-1, true)
+			}
+		}
+		progressBar.Wait()
+
+		color.Green(">> Successfully extracted %d highlight clips", len(clips))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(extractHighlightsCmd)
+	extractHighlightsCmd.Flags().StringP("input", "i", "", "MP4 File or directory with MP4 files")
+	extractHighlightsCmd.Flags().StringP("output", "o", "", "Output directory, do not specify to place clips next to the source")
+	extractHighlightsCmd.Flags().String("before", "", "Seconds to keep before each tag (default: 5)")
+	extractHighlightsCmd.Flags().String("after", "", "Seconds to keep after each tag (default: 3)")
+	extractHighlightsCmd.Flags().String("keyframe-tolerance", "", "Max seconds a clip can be extended back to the previous keyframe to allow a lossless cut (default: 1)")
+	extractHighlightsCmd.Flags().String("reencode", "", "Always re-encode clips instead of stream copying (default: false)")
+	extractHighlightsCmd.Flags().String("reel", "", "Concatenate all clips into this file")
+
+	_ = extractHighlightsCmd.MarkFlagRequired("input")
+}
@@ -65,3 +65,21 @@ func getFlagBool(cmd *cobra.Command, name string, defaultBool string) bool {
 	}
 	return bool1
 }
+
+func getFlagFloat(cmd *cobra.Command, name string, defaultFloat string) float64 {
+	value, err := cmd.Flags().GetString(name)
+	if err != nil {
+		cui.Error("Problem parsing "+name, err)
+	}
+	if value == "" {
+		value = viper.GetString(name)
+	}
+	if value == "" {
+		value = defaultFloat
+	}
+	float1, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		cui.Error("Problem parsing "+value, err)
+	}
+	return float1
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/erdaltsksn/cui"
@@ -47,6 +48,7 @@ var importCmd = &cobra.Command{
 		cameraName := getFlagString(cmd, "camera-name")
 		connection := utils.ConnectionType(getFlagString(cmd, "connection"))
 		skipAuxFiles := getFlagBool(cmd, "skip-aux", "true")
+		skipIncomplete := getFlagBool(cmd, "skip-incomplete", "false")
 		sortBy := getFlagSlice(cmd, "sort-by")
 		if len(sortBy) == 0 {
 			sortBy = []string{"camera", "location"}
@@ -56,6 +58,27 @@ var importCmd = &cobra.Command{
 			ByCamera:   slices.Contains(sortBy, "camera"),
 		}
 		tagNames := getFlagSlice(cmd, "tag-names")
+		devicePaths := getFlagSlice(cmd, "device-paths")
+		sequenceOptions := utils.SequenceOptions{
+			Render:    getFlagBool(cmd, "render-sequences", "false"),
+			FrameRate: getFlagInt(cmd, "sequence-fps", "30"),
+		}
+		proxies := getFlagBool(cmd, "proxies", "false")
+		sidecars := getFlagBool(cmd, "xmp", "false")
+		rawMode, err := utils.RawModeGet(getFlagString(cmd, "raw"))
+		if err != nil {
+			cui.Error("Unknown raw mode, use one of separate, pair, raw, jpeg", err)
+		}
+		chapterOptions := utils.ChapterOptions{
+			Merge: getFlagBool(cmd, "merge-chapters", "false"),
+			Keep:  getFlagBool(cmd, "keep-chapters", "true"),
+		}
+
+		// several Connect cameras can be given as a comma separated list
+		connectIPs := []string{}
+		if connection == utils.Connect {
+			connectIPs = strings.Split(input, ",")
+		}
 
 		if useGoPro, err := cmd.Flags().GetBool("use-gopro"); err == nil && useGoPro {
 			detectedGoPro, connectionType, err := gopro.Detect()
@@ -65,6 +88,12 @@ var importCmd = &cobra.Command{
 			input = detectedGoPro
 			connection = connectionType
 			camera = "gopro"
+			if connection == utils.Connect {
+				connectIPs, err = gopro.DetectConnect()
+				if err != nil {
+					cui.Error(err.Error())
+				}
+			}
 		} else if useInsta360, err := cmd.Flags().GetBool("use-insta360"); err == nil && useInsta360 {
 			detectedInsta360, connectionType, err := insta360.Detect()
 			if err != nil {
@@ -93,14 +122,34 @@ var importCmd = &cobra.Command{
 				Output:             filepath.Join(output, projectName),
 				CameraName:         cameraName,
 				SkipAuxiliaryFiles: skipAuxFiles,
+				SkipIncomplete:     skipIncomplete,
+				Sidecars:           sidecars,
 				DateFormat:         dateFormat,
 				BufferSize:         bufferSize,
 				Prefix:             prefix,
 				DateRange:          parseDateRange(dateRange, dateFormat),
 				TagNames:           tagNames,
+				DevicePaths:        devicePaths,
 				Connection:         connection,
 				Sort:               sortOptions,
+				Sequences:          sequenceOptions,
+				Chapters:           chapterOptions,
+				RawMode:            rawMode,
+			}
+
+			existing := map[string]bool{}
+			if proxies {
+				existing = listFiles(params.Output)
 			}
+
+			if c == utils.GoPro && connection == utils.Connect && len(connectIPs) > 1 {
+				importFromConnectCameras(params, connectIPs)
+				if proxies {
+					importProxies(cmd, params.Output, existing)
+				}
+				return
+			}
+
 			r, err := importFromCamera(c, params)
 			if err != nil {
 				cui.Error("Something went wrong", err)
@@ -123,6 +172,9 @@ var importCmd = &cobra.Command{
 					color.Red(">> " + error.Error())
 				}
 			}
+			if proxies {
+				importProxies(cmd, params.Output, existing)
+			}
 			return
 		}
 		color.Red("Error: required flag(s) \"camera\", \"output\" not set")
@@ -133,7 +185,7 @@ func init() {
 	rootCmd.AddCommand(importCmd)
 
 	importCmd.PersistentFlags().BoolP("verbose", "v", false, "Verbose")
-	importCmd.Flags().StringP("input", "i", "", "Input directory for root, eg: E:\\")
+	importCmd.Flags().StringP("input", "i", "", "Input directory for root, eg: E:\\ - or comma separated IPs for GoPro Connect")
 	importCmd.Flags().StringP("output", "o", "", "Output directory for sorted media")
 	importCmd.Flags().StringP("name", "n", "", "Project name")
 	importCmd.Flags().StringP("camera", "c", "", "Camera type")
@@ -141,15 +193,28 @@ func init() {
 	importCmd.Flags().StringP("buffer", "b", "", "Buffer size for copying, default is 1000 bytes")
 	importCmd.Flags().StringP("prefix", "p", "", "Prefix for each file, pass `cameraname` to prepend the camera name (eg: Hero9 Black)")
 	importCmd.Flags().StringSlice("range", []string{}, "A date range, eg: 01-05-2020,05-05-2020 -- also accepted: `today`, `yesterday`, `week`")
-	importCmd.Flags().StringP("connection", "x", "", "Connexion type: `sd_card`, `connect` (GoPro-specific)")
+	importCmd.Flags().StringP("connection", "x", "", "Connexion type: `sd_card`, `connect` (GoPro Connect, Insta360 WiFi)")
 	importCmd.Flags().StringSlice("sort-by", []string{}, "Sort files by: `camera`, `location`")
 	importCmd.Flags().StringSlice("tag-names", []string{}, "Tag names for number of HiLight tags in last 10s of video, each position being the amount, eg: 'marked 1,good stuff,important' => num of tags: 1,2,3")
-	importCmd.Flags().StringP("skip-aux", "s", "true", "Skip auxiliary files (GoPro: THM, LRV. DJI: SRT)")
+	importCmd.Flags().StringSlice("device-paths", []string{}, "Android folders to import, relative to /sdcard (default: DCIM/Camera,Pictures,Movies,Download)")
+	importCmd.Flags().StringP("skip-aux", "s", "true", "Skip auxiliary files (GoPro: THM, LRV. DJI: SRT, LRF)")
+	importCmd.Flags().String("skip-incomplete", "", "Skip Insta360 clips missing a lens file or truncated instead of warning (default: false)")
 	importCmd.Flags().String("camera-name", "", "Override camera name detection with specified string")
+	importCmd.Flags().String("render-sequences", "", "Render bursts, timelapses and night-lapses to MP4 (default: false)")
+	importCmd.Flags().String("sequence-fps", "", "Frame rate of rendered sequences (default: 30)")
+	importCmd.Flags().String("merge-chapters", "", "Merge complete sets of GoPro chapters into a single video (default: false)")
+	importCmd.Flags().String("keep-chapters", "", "Keep the individual chapters after merging them (default: true)")
+	importCmd.Flags().String("raw", "", "Raw+JPEG pairs: `separate` (raws in photos/raw), `pair` (side by side), `raw` or `jpeg` to keep one of them (default: separate)")
+	importCmd.Flags().String("xmp", "", "Write XMP sidecars with location, place names, camera, keywords and rating for imported videos and photos, raws always get one (default: false)")
+	importCmd.Flags().String("proxies", "", "Generate editing proxies for videos imported without an LRV (default: false)")
+	importCmd.Flags().String("proxy-preset", "", "Proxy preset: h264, prores, dnxhr (default: h264)")
+	importCmd.Flags().String("proxy-height", "", "Proxy height in pixels (default: 720, 1080 for dnxhr)")
+	importCmd.Flags().String("proxy-bitrate", "", "Video bitrate for h264 proxies (default: 5M)")
+	importCmd.Flags().String("proxy-jobs", "", "Proxies encoded at once (default: 2)")
 
 	// Camera helpers
 	importCmd.Flags().Bool("use-gopro", false, "Detect GoPro camera attached")
-	importCmd.Flags().Bool("use-insta360", false, "Detect Insta360 camera attached")
+	importCmd.Flags().Bool("use-insta360", false, "Detect Insta360 camera attached, or connected over WiFi")
 }
 
 func parseDateRange(dateRange []string, dateFormat string) []time.Time {
@@ -190,6 +255,55 @@ func parseDateRange(dateRange []string, dateFormat string) []time.Time {
 	return []time.Time{dateStart, dateEnd}
 }
 
+// importProxies encodes proxies for the videos this import added to output
+func importProxies(cmd *cobra.Command, output string, existing map[string]bool) {
+	options := getProxyOptions(cmd, "proxy-")
+	videos, err := importedProxyCandidates(output, existing, options)
+	if err != nil {
+		printProxyResult(0, []error{err})
+		return
+	}
+	printProxyResult(generateProxies(videos, options))
+}
+
+func importFromConnectCameras(params utils.ImportParams, ips []string) {
+	results := gopro.ImportConnectAll(params, ips)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Camera", "Files Imported", "Files Skipped", "Errors"})
+	imported, skipped, errored := 0, 0, 0
+	for _, r := range results {
+		name := r.Device.IP
+		if r.Device.Info.Info.ModelName != "" {
+			name = fmt.Sprintf("%s (%s)", r.Device.Info.Info.ModelName, r.Device.IP)
+		}
+		if r.Err != nil {
+			table.Append([]string{name, "0", "0", "1"})
+			errored++
+			continue
+		}
+		table.Append([]string{name, strconv.Itoa(r.Result.FilesImported), strconv.Itoa(len(r.Result.FilesNotImported)), strconv.Itoa(len(r.Result.Errors))})
+		imported += r.Result.FilesImported
+		skipped += len(r.Result.FilesNotImported)
+		errored += len(r.Result.Errors)
+	}
+	table.SetFooter([]string{"Total", strconv.Itoa(imported), strconv.Itoa(skipped), strconv.Itoa(errored)})
+	table.Render() // Send output
+
+	if errored != 0 {
+		fmt.Println("Errors: ")
+		for _, r := range results {
+			if r.Err != nil {
+				color.Red(">> %s: %s", r.Device.IP, r.Err.Error())
+				continue
+			}
+			for _, error := range r.Result.Errors {
+				color.Red(">> %s: %s", r.Device.IP, error.Error())
+			}
+		}
+	}
+}
+
 func callImport(cameraIf utils.Import, params utils.ImportParams) (*utils.Result, error) {
 	return cameraIf.Import(params)
 }
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/erdaltsksn/cui"
+	"github.com/konradit/mmt/pkg/insta360"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func printInsta360Metadata(path string, metadata *insta360.Metadata) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Field", "Value"})
+	table.SetAutoWrapText(false)
+
+	captureTime := ""
+	if !metadata.CaptureTime.IsZero() {
+		captureTime = metadata.CaptureTime.Format(time.RFC3339)
+	}
+	table.AppendBulk([][]string{
+		{"File", filepath.Base(path)},
+		{"Model", metadata.Model},
+		{"Serial Number", metadata.SerialNumber},
+		{"Firmware", metadata.Firmware},
+		{"Capture Time", captureTime},
+		{"Lens Offset", metadata.LensOffset},
+		{"Gyro Samples", fmt.Sprint(len(metadata.Gyro))},
+		{"Exposure Samples", fmt.Sprint(len(metadata.Exposure))},
+		{"GPS Samples", fmt.Sprint(len(metadata.GPS))},
+	})
+	for _, gps := range metadata.GPS {
+		if gps.Valid {
+			table.Append([]string{"First GPS Fix", fmt.Sprintf("%.6f, %.6f (%.1fm) at %s", gps.Latitude, gps.Longitude, gps.Altitude, gps.Time.Format(time.RFC3339))})
+			break
+		}
+	}
+	table.Render()
+}
+
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show the metadata embedded in a media file",
+	Run: func(cmd *cobra.Command, args []string) {
+		input := getFlagString(cmd, "input")
+		if input == "" && len(args) > 0 {
+			input = args[0]
+		}
+		if input == "" {
+			cui.Error("Pass a file with --input")
+		}
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		switch strings.ToLower(filepath.Ext(input)) {
+		case ".insv", ".insp", ".mp4", ".lrv":
+			metadata, err := insta360.ReadMetadata(input)
+			if err != nil {
+				cui.Error("No Insta360 metadata in "+input, err)
+			}
+			if !asJSON {
+				printInsta360Metadata(input, metadata)
+				return
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(metadata); err != nil {
+				cui.Error(err.Error())
+			}
+		default:
+			cui.Error("Unsupported file, info reads Insta360 .insv, .insp and .mp4 files")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+	infoCmd.Flags().StringP("input", "i", "", "Media file")
+	infoCmd.Flags().Bool("json", false, "Dump every record, including gyro, exposure and GPS samples, as JSON")
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/erdaltsksn/cui"
+	"github.com/fatih/color"
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/nfnt/resize"
+	"github.com/spf13/cobra"
+)
+
+type previewLUT struct {
+	Name    string
+	Options lutOptions
+}
+
+// samplePhotos picks count photos spread evenly over the sorted photos
+func samplePhotos(photos []string, count int) []string {
+	if count <= 0 || len(photos) <= count {
+		return photos
+	}
+	samples := []string{}
+	for i := 0; i < count; i++ {
+		samples = append(samples, photos[i*len(photos)/count])
+	}
+	return samples
+}
+
+// findPreviewLUTs expands folders to the LUT files inside them
+func findPreviewLUTs(paths []string) ([]string, error) {
+	luts := []string{}
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !stat.IsDir() {
+			luts = append(luts, path)
+			continue
+		}
+		files, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			ext := strings.ToLower(filepath.Ext(file.Name()))
+			if !file.IsDir() && (ext == ".cube" || ext == ".3dl") {
+				luts = append(luts, filepath.Join(path, file.Name()))
+			}
+		}
+	}
+	return luts, nil
+}
+
+func previewLabel(lutFilename string, intensity int) string {
+	return fmt.Sprintf("%s %d%%", strings.TrimSuffix(filepath.Base(lutFilename), filepath.Ext(lutFilename)), intensity)
+}
+
+// lutPreviewRow is the photo untouched followed by one cell per LUT and intensity
+func lutPreviewRow(photo string, luts []previewLUT, width int) ([]utils.ContactSheetCell, error) {
+	srcImg, _, err := utils.DecodeImage(photo)
+	if err != nil {
+		return nil, err
+	}
+	thumbnail := resize.Resize(uint(width), 0, srcImg, resize.Lanczos3)
+
+	row := []utils.ContactSheetCell{{Image: thumbnail, Label: filepath.Base(photo)}}
+	for _, lut := range luts {
+		graded, err := gradeImage(thumbnail, lut.Options)
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, utils.ContactSheetCell{Image: graded, Label: lut.Name})
+	}
+	return row, nil
+}
+
+var lutPreviewCmd = &cobra.Command{
+	Use:   "lut-preview",
+	Short: "Render a contact sheet of sample photos graded with several LUTs",
+	Run: func(cmd *cobra.Command, args []string) {
+		input := getFlagString(cmd, "input")
+		output := getFlagString(cmd, "output")
+		count := getFlagInt(cmd, "samples", "4")
+		width := getFlagInt(cmd, "width", "320")
+		quality := getFlagInt(cmd, "quality", "90")
+		if width < 16 {
+			cui.Error("Thumbnail width has to be at least 16 pixels")
+		}
+
+		lutFiles, err := findPreviewLUTs(getFlagSlice(cmd, "lut"))
+		if err != nil {
+			cui.Error(err.Error())
+		}
+		if len(lutFiles) == 0 {
+			cui.Error("Pass at least one LUT with --lut")
+		}
+		intensities := getFlagSlice(cmd, "intensity")
+		if len(intensities) == 0 {
+			intensities = []string{"100"}
+		}
+
+		luts := []previewLUT{}
+		for _, lutFile := range lutFiles {
+			cf, err := utils.ReadLUT(lutFile)
+			if err != nil {
+				cui.Error("Problem reading LUT "+lutFile, err)
+			}
+			for _, value := range intensities {
+				intensity, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+				if err != nil || intensity < 1 || intensity > 100 {
+					cui.Error("Intensity has to be between 1 and 100, got " + value)
+				}
+				luts = append(luts, previewLUT{
+					Name:    previewLabel(lutFile, intensity),
+					Options: lutOptions{LUT: cf, LUTPath: lutFile, Intensity: float64(intensity) / 100},
+				})
+			}
+		}
+
+		if output == "" {
+			folder := input
+			if stat, err := os.Stat(input); err == nil && !stat.IsDir() {
+				folder = filepath.Dir(input)
+			}
+			output = filepath.Join(folder, "lut-preview.jpg")
+		}
+		format, ok := utils.ImageFormat(output)
+		if !ok {
+			cui.Error("Contact sheet has to be a .jpg, .png or .tiff")
+		}
+
+		found, _, err := findLUTInputs(input, false)
+		if err != nil {
+			cui.Error(err.Error())
+		}
+		photos := []string{}
+		for _, photo := range found {
+			if filepath.Clean(photo) != filepath.Clean(output) {
+				photos = append(photos, photo)
+			}
+		}
+		if len(photos) == 0 {
+			cui.Error("No JPG/PNG/TIFF photos found in " + input)
+		}
+
+		rows := [][]utils.ContactSheetCell{}
+		for _, photo := range samplePhotos(photos, count) {
+			color.Yellow(">> Grading sample: %s...", filepath.Base(photo))
+			row, err := lutPreviewRow(photo, luts, width)
+			if err != nil {
+				color.Red(">> %s: %s", filepath.Base(photo), err.Error())
+				continue
+			}
+			rows = append(rows, row)
+		}
+		if len(rows) == 0 {
+			cui.Error("None of the sample photos could be graded")
+		}
+
+		err = utils.EncodeImage(output, utils.ContactSheet(rows, width), format, quality, nil)
+		if err != nil {
+			cui.Error("Problem writing contact sheet", err)
+		}
+		color.Green(">> Contact sheet written to: %s", output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lutPreviewCmd)
+	lutPreviewCmd.Flags().StringP("input", "i", "", "JPG/PNG/TIFF photo or directory with them")
+	lutPreviewCmd.Flags().StringSliceP("lut", "l", []string{}, "LUT files or directories of .cube/.3dl LUTs to compare")
+	lutPreviewCmd.Flags().StringSlice("intensity", []string{}, "Intensities from 1 - 100 to render each LUT at (default: 100)")
+	lutPreviewCmd.Flags().StringP("output", "o", "", "Contact sheet path, .jpg, .png or .tiff (default: lut-preview.jpg in input)")
+	lutPreviewCmd.Flags().String("samples", "", "Number of photos sampled from input (default: 4)")
+	lutPreviewCmd.Flags().String("width", "", "Thumbnail width in pixels (default: 320)")
+	lutPreviewCmd.Flags().String("quality", "", "JPG quality (default: 90)")
+}
@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/erdaltsksn/cui"
+	"github.com/fatih/color"
+	"github.com/konradit/mmt/pkg/gopro"
 	"github.com/konradit/mmt/pkg/utils"
 	"github.com/konradit/mmt/pkg/videomanipulation"
 	"github.com/spf13/cobra"
@@ -13,40 +16,84 @@ import (
 	"github.com/vbauerster/mpb/v8/decor"
 )
 
+// getMergeSets turns --input into lists of videos to merge: a folder or a single chapter is expanded into its recordings
+func getMergeSets(videos []string) ([][]string, error) {
+	if len(videos) == 1 {
+		return gopro.FindChapterSets(videos[0])
+	}
+	return [][]string{videos}, nil
+}
+
+// getMergeOutput is --output, the folder it points to when merging several recordings or the default next to the chapters
+func getMergeOutput(output string, videos []string, several bool) string {
+	switch {
+	case output == "":
+		return gopro.MergedChapterName(videos)
+	case several:
+		return filepath.Join(output, filepath.Base(gopro.MergedChapterName(videos)))
+	}
+	return output
+}
+
 var mergeCmd = &cobra.Command{
 	Use:   "merge",
 	Short: "Merge two or more videos together",
 	Run: func(cmd *cobra.Command, args []string) {
-		videoMan := videomanipulation.New()
 		videos := getFlagSlice(cmd, "input")
-		totalFrames := 0
-		ffprobe := utils.NewFFprobe(nil)
-		for i := 0; i < len(videos); i++ {
-			head, err := ffprobe.Frames(videos[i])
-			if err != nil {
-				cui.Error(err.Error())
+		if len(videos) == 0 {
+			cui.Error("Pass the videos to merge, a folder or one chapter of a recording with --input")
+		}
+		sets, err := getMergeSets(videos)
+		if err != nil {
+			cui.Error(err.Error())
+		}
+
+		output := getFlagString(cmd, "output")
+		if _, isChapter := gopro.GetChapterInfo(sets[0][0]); output == "" && !isChapter {
+			cui.Error("Pass --output to choose where the merged video goes")
+		}
+		if len(sets) > 1 && output != "" {
+			if stat, err := os.Stat(output); err != nil || !stat.IsDir() {
+				cui.Error("--output must be an existing folder when merging several recordings")
 			}
-			totalFrames += head.Streams[0].Frames
 		}
 
+		ffprobe := utils.NewFFprobe(nil)
 		nonAsync := mpb.New(
 			mpb.WithWidth(60),
 			mpb.WithRefreshRate(180*time.Millisecond))
-		newBar := nonAsync.AddBar(int64(totalFrames),
-			mpb.PrependDecorators(
-				decor.Name(fmt.Sprintf("%s%s", "🐈", filepath.Base(videos[0]))),
-				decor.Percentage(decor.WCSyncSpace),
-			),
-			mpb.AppendDecorators(
-				decor.OnComplete(
-					decor.EwmaETA(decor.ET_STYLE_GO, 60, decor.WCSyncWidth), "✔️",
+
+		for _, set := range sets {
+			merged := getMergeOutput(output, set, len(sets) > 1)
+
+			totalFrames := 0
+			for _, video := range set {
+				head, err := ffprobe.Frames(video)
+				if err != nil {
+					cui.Error(err.Error())
+				}
+				totalFrames += head.Streams[0].Frames
+			}
+
+			newBar := nonAsync.AddBar(int64(totalFrames),
+				mpb.PrependDecorators(
+					decor.Name(fmt.Sprintf("%s%s", "🐈", filepath.Base(merged))),
+					decor.Percentage(decor.WCSyncSpace),
 				),
-			),
-		)
+				mpb.AppendDecorators(
+					decor.OnComplete(
+						decor.EwmaETA(decor.ET_STYLE_GO, 60, decor.WCSyncWidth), "✔️",
+					),
+				),
+			)
 
-		err := videoMan.Merge(newBar, videos...)
-		if err != nil {
-			cui.Error(err.Error())
+			err = videomanipulation.New().Merge(merged, newBar, set...)
+			if err != nil {
+				newBar.Abort(false)
+				color.Red(">> %s", err.Error())
+				continue
+			}
+			newBar.SetTotal(-1, true)
 		}
 		nonAsync.Wait()
 	},
@@ -54,5 +101,6 @@ var mergeCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(mergeCmd)
-	mergeCmd.Flags().StringSlice("input", []string{}, "File to merge")
+	mergeCmd.Flags().StringSlice("input", []string{}, "Files to merge, a folder or one chapter of a recording")
+	mergeCmd.Flags().StringP("output", "o", "", "Merged video, or folder when merging several recordings (default: next to the chapters)")
 }
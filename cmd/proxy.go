@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/erdaltsksn/cui"
+	"github.com/fatih/color"
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/konradit/mmt/pkg/videomanipulation"
+	"github.com/spf13/cobra"
+	"github.com/vbauerster/mpb/v8"
+)
+
+type proxyOptions struct {
+	Preset videomanipulation.ProxyPreset
+	Jobs   int
+	Force  bool
+}
+
+// getProxyOptions reads the preset and its overrides, import prefixes them with proxy-
+func getProxyOptions(cmd *cobra.Command, prefix string) proxyOptions {
+	name := getFlagString(cmd, prefix+"preset")
+	if name == "" {
+		name = "h264"
+	}
+	preset, err := videomanipulation.GetProxyPreset(name)
+	if err != nil {
+		cui.Error("Unknown proxy preset, use one of h264, prores, dnxhr", err)
+	}
+	preset.Height = getFlagInt(cmd, prefix+"height", "0")
+	if preset.Height == 0 {
+		preset.Height = videomanipulation.ProxyPresets[name].Height
+	}
+	if bitrate := getFlagString(cmd, prefix+"bitrate"); bitrate != "" {
+		preset.Bitrate = bitrate
+	}
+	jobs := getFlagInt(cmd, prefix+"jobs", "2")
+	if jobs < 1 {
+		jobs = 1
+	}
+	return proxyOptions{Preset: preset, Jobs: jobs}
+}
+
+// findProxyCandidates lists the videos under input that have neither a camera proxy nor one made by mmt
+func findProxyCandidates(input string, options proxyOptions) ([]string, error) {
+	videos := []string{}
+	err := filepath.Walk(input, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !videomanipulation.IsProxyCandidate(path) {
+			return nil
+		}
+		if !options.Force && videomanipulation.HasProxy(path, options.Preset) {
+			return nil
+		}
+		videos = append(videos, path)
+		return nil
+	})
+	return videos, err
+}
+
+/*
+importedProxyCandidates lists the proxy candidates under output that were not among existing before the import,
+so --proxies leaves the rest of the project alone.
+*/
+func importedProxyCandidates(output string, existing map[string]bool, options proxyOptions) ([]string, error) {
+	videos, err := findProxyCandidates(output, options)
+	if err != nil {
+		return nil, err
+	}
+	imported := []string{}
+	for _, video := range videos {
+		if !existing[video] {
+			imported = append(imported, video)
+		}
+	}
+	return imported, nil
+}
+
+// listFiles lists every file under output, an output that does not exist yet has none
+func listFiles(output string) map[string]bool {
+	files := map[string]bool{}
+	_ = filepath.Walk(output, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files[path] = true
+		}
+		return nil
+	})
+	return files
+}
+
+// generateProxies encodes proxies for videos, at most options.Jobs at once
+func generateProxies(videos []string, options proxyOptions) (int, []error) {
+	progressBar := mpb.New(
+		mpb.WithWidth(60),
+		mpb.WithRefreshRate(180*time.Millisecond))
+
+	ffprobe := utils.NewFFprobe(nil)
+	inlineCounter := utils.ResultCounter{}
+	jobs := make(chan struct{}, options.Jobs)
+	wg := sync.WaitGroup{}
+	for _, video := range videos {
+		frames := int64(0)
+		if framesResp, err := ffprobe.Frames(video); err == nil && len(framesResp.Streams) > 0 {
+			frames = int64(framesResp.Streams[0].Frames)
+		}
+		output := videomanipulation.ProxyPath(video, options.Preset.Ext)
+		bar := utils.GetNewBar(progressBar, frames, filepath.Base(output), utils.Percentage)
+
+		wg.Add(1)
+		jobs <- struct{}{}
+		go func(video, output string, bar *mpb.Bar) {
+			defer wg.Done()
+			defer func() { <-jobs }()
+			err := videomanipulation.New().Proxy(video, output, options.Preset, bar)
+			if err != nil {
+				bar.Abort(false)
+				inlineCounter.SetFailure(err, filepath.Base(video))
+				return
+			}
+			bar.SetTotal(-1, true)
+			inlineCounter.SetSuccess()
+		}(video, output, bar)
+	}
+	wg.Wait()
+	progressBar.Wait()
+
+	return inlineCounter.Get().FilesImported, inlineCounter.Get().Errors
+}
+
+func printProxyResult(generated int, errors []error) {
+	color.Green(">> Generated %d proxies", generated)
+	for _, err := range errors {
+		color.Red(">> " + err.Error())
+	}
+}
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Generate editing proxies for videos without a camera made LRV",
+	Run: func(cmd *cobra.Command, args []string) {
+		input := getFlagString(cmd, "input")
+		if input == "" {
+			cui.Error("Pass a video or a folder of imported media with --input")
+		}
+		options := getProxyOptions(cmd, "")
+		options.Force, _ = cmd.Flags().GetBool("force")
+
+		videos, err := findProxyCandidates(input, options)
+		if err != nil {
+			cui.Error("Something went wrong", err)
+		}
+		printProxyResult(generateProxies(videos, options))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(proxyCmd)
+	proxyCmd.Flags().StringP("input", "i", "", "Video or folder of imported media")
+	proxyCmd.Flags().String("preset", "", "Proxy preset: h264, prores (ProRes Proxy), dnxhr (DNxHR LB) (default: h264)")
+	proxyCmd.Flags().String("height", "", "Proxy height in pixels (default: 720, 1080 for dnxhr)")
+	proxyCmd.Flags().String("bitrate", "", "Video bitrate for h264 proxies (default: 5M)")
+	proxyCmd.Flags().String("jobs", "", "Proxies encoded at once (default: 2)")
+	proxyCmd.Flags().Bool("force", false, "Encode proxies again even if one already exists")
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/erdaltsksn/cui"
+	"github.com/konradit/mmt/pkg/videomanipulation"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -20,6 +22,9 @@ var rootCmd = &cobra.Command{
 	- Insta360
 	- DJI
 	- Android phone`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		videomanipulation.SetHWAccel(getHWAccel(cmd))
+	},
 }
 
 func Execute() {
@@ -29,6 +34,25 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.mmt.yaml)")
+	rootCmd.PersistentFlags().String("hwaccel", "", "FFmpeg hardware acceleration: auto, none, vaapi, qsv, videotoolbox, cuda... (default: auto)")
+}
+
+// getHWAccel reads --hwaccel, then <command>.hwaccel and hwaccel from the config file
+func getHWAccel(cmd *cobra.Command) string {
+	value, err := cmd.Flags().GetString("hwaccel")
+	if err != nil {
+		cui.Error("Problem parsing hwaccel", err)
+	}
+	if value == "" {
+		value = viper.GetString(cmd.Name() + ".hwaccel")
+	}
+	if value == "" {
+		value = viper.GetString("hwaccel")
+	}
+	if value == "" {
+		value = videomanipulation.HWAccelAuto
+	}
+	return value
 }
 
 func initConfig() {
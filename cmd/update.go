@@ -38,5 +38,5 @@ func init() {
 	rootCmd.AddCommand(updateCmd)
 	updateCmd.Flags().StringP("input", "i", "", "Input directory for root sd card, eg: E:\\")
 	updateCmd.Flags().StringP("camera", "c", "", "Camera type")
-	updateCmd.Flags().StringP("model", "m", "", "Model type (for insta360): oner, onex, onex2")
+	updateCmd.Flags().StringP("model", "m", "", "Model type (for insta360): oner, onex, onex2, go2, x3, x4, go3, ace, ace-pro. Detected from the card when empty")
 }
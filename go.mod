@@ -27,6 +27,7 @@ require (
 	github.com/xfrr/goffmpeg v0.0.0-20210624103149-5ca2d3062daf
 	github.com/zach-klippenstein/goadb v0.0.0-20201208042340-620e0e950ed7
 	golang.org/x/exp v0.0.0-20230105000112-eab7a2c85304
+	golang.org/x/image v0.10.0
 	gopkg.in/djherbis/times.v1 v1.2.0
 )
 
@@ -71,11 +72,11 @@ require (
 	github.com/subosito/gotenv v1.2.0 // indirect
 	github.com/xanzy/ssh-agent v0.2.1 // indirect
 	golang.org/x/crypto v0.1.0 // indirect
-	golang.org/x/mod v0.6.0 // indirect
-	golang.org/x/net v0.1.0 // indirect
-	golang.org/x/sys v0.1.0 // indirect
-	golang.org/x/text v0.4.0 // indirect
-	golang.org/x/tools v0.2.0 // indirect
+	golang.org/x/mod v0.8.0 // indirect
+	golang.org/x/net v0.6.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/text v0.11.0 // indirect
+	golang.org/x/tools v0.6.0 // indirect
 	gopkg.in/ini.v1 v1.57.0 // indirect
 	gopkg.in/src-d/go-billy.v4 v4.3.2 // indirect
 	gopkg.in/src-d/go-git.v4 v4.12.0 // indirect
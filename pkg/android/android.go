@@ -1,14 +1,18 @@
 package android
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/fatih/color"
+	mErrors "github.com/konradit/mmt/pkg/errors"
 	"github.com/konradit/mmt/pkg/utils"
 	"github.com/vbauerster/mpb/v8"
 	adb "github.com/zach-klippenstein/goadb"
@@ -48,13 +52,13 @@ func prepare(out string, deviceFileName string, deviceModel string, mediaDate st
 
 	bar := utils.GetNewBar(progressBar, stat.Size(), deviceFileName, utils.IoTX)
 
-	dayFolder := utils.GetOrder(sortOptions, locationService, filepath.Join(out, localFile.Name()), out, mediaDate, deviceModel)
+	dayFolder := utils.GetOrder(sortOptions, locationService, localFile.Name(), out, mediaDate, deviceModel)
 
 	err = localFile.Close()
 	if err != nil {
 		return nil, "", err
 	}
-	err = os.Remove(filepath.Join(out, localFile.Name()))
+	err = os.Remove(localFile.Name())
 	if err != nil {
 		return nil, "", err
 	}
@@ -63,6 +67,90 @@ func prepare(out string, deviceFileName string, deviceModel string, mediaDate st
 
 type Entrypoint struct{}
 
+type deviceFile struct {
+	path     string
+	name     string
+	modified time.Time
+	ftype    FileTypeMatch
+}
+
+// listFiles walks folder on the device, hidden folders such as .thumbnails and .trashed left out
+func listFiles(device *adb.Device, folder string) ([]deviceFile, error) {
+	entries, err := device.ListDirEntries(folder)
+	if err != nil {
+		return nil, err
+	}
+	files := []deviceFile{}
+	folders := []string{}
+	for entries.Next() {
+		entry := entries.Entry()
+		if strings.HasPrefix(entry.Name, ".") {
+			continue
+		}
+		if entry.Mode.IsDir() {
+			folders = append(folders, path.Join(folder, entry.Name))
+			continue
+		}
+		ftype, ok := fileTypeOf(entry.Name)
+		if !ok {
+			continue
+		}
+		files = append(files, deviceFile{
+			path:     path.Join(folder, entry.Name),
+			name:     entry.Name,
+			modified: entry.ModifiedAt,
+			ftype:    ftype,
+		})
+	}
+	if err := entries.Err(); err != nil {
+		return nil, err
+	}
+	entries.Close()
+
+	for _, subfolder := range folders {
+		subfiles, err := listFiles(device, subfolder)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, subfiles...)
+	}
+	return files, nil
+}
+
+// pairedPhotos lists the photos shot as raw+JPEG, by folder and pairKey
+func pairedPhotos(files []deviceFile) map[string]bool {
+	raws, jpegs := map[string]bool{}, map[string]bool{}
+	for _, file := range files {
+		key := path.Join(path.Dir(file.path), pairKey(file.name))
+		switch file.ftype.Type {
+		case RawPhoto:
+			raws[key] = true
+		case Photo:
+			jpegs[key] = true
+		}
+	}
+	paired := map[string]bool{}
+	for key := range raws {
+		paired[key] = jpegs[key]
+	}
+	return paired
+}
+
+// claimedPaths are the files an import writes, same-named files from different folders on the phone get a suffix
+type claimedPaths map[string]bool
+
+// claim returns where to save a file meant for localPath and whether it had to be renamed
+func (c claimedPaths) claim(localPath string) (string, bool) {
+	extension := filepath.Ext(localPath)
+	base := strings.TrimSuffix(localPath, extension)
+	candidate := localPath
+	for i := 2; c[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s_%d%s", base, i, extension)
+	}
+	c[strings.ToLower(candidate)] = true
+	return candidate, candidate != localPath
+}
+
 func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 	var result utils.Result
 
@@ -83,18 +171,29 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 	}
 	device := client.Device(deviceDescriptor)
 
-	entries, err := device.ListDirEntries("/sdcard/DCIM/Camera")
+	deviceInfo, err := device.DeviceInfo()
 	if err != nil {
 		return nil, err
 	}
-	if entries.Err() != nil {
-		return nil, err
+	deviceName := deviceInfo.Product
+	if params.CameraName != "" {
+		deviceName = params.CameraName
 	}
 
-	deviceInfo, err := device.DeviceInfo()
-	if err != nil {
-		return nil, err
+	devicePaths := params.DevicePaths
+	if len(devicePaths) == 0 {
+		devicePaths = DefaultPaths
 	}
+	files := []deviceFile{}
+	for _, devicePath := range devicePaths {
+		found, err := listFiles(device, path.Join("/sdcard", devicePath))
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+		files = append(files, found...)
+	}
+	paired := pairedPhotos(files)
 
 	var wg sync.WaitGroup
 	progressBar := mpb.New(mpb.WithWaitGroup(&wg),
@@ -102,112 +201,120 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 		mpb.WithRefreshRate(180*time.Millisecond))
 
 	inlineCounter := utils.ResultCounter{}
+	claimed := claimedPaths{}
 
-	for entries.Next() {
-		mediaDate := entries.Entry().ModifiedAt.Format("02-01-2006")
-		if strings.Contains(params.DateFormat, "yyyy") && strings.Contains(params.DateFormat, "mm") && strings.Contains(params.DateFormat, "dd") {
-			mediaDate = entries.Entry().ModifiedAt.Format(replacer.Replace(params.DateFormat))
+	for _, file := range files {
+		d := file.modified
+		if captured, found := captureTime(file.name); found {
+			d = captured
 		}
 
 		// check if is in date range
-		if entries.Entry().ModifiedAt.Before(params.DateRange[0]) || entries.Entry().ModifiedAt.After(params.DateRange[0]) {
+		if d.Before(params.DateRange[0]) || d.After(params.DateRange[1]) {
 			continue
 		}
 
+		isRaw := file.ftype.Type == RawPhoto
+		isPaired := paired[path.Join(path.Dir(file.path), pairKey(file.name))]
+		if file.ftype.Type != Video && !params.RawMode.Keep(isRaw, isPaired) {
+			continue
+		}
+
+		mediaDate := d.Format("02-01-2006")
+		if strings.Contains(params.DateFormat, "yyyy") && strings.Contains(params.DateFormat, "mm") && strings.Contains(params.DateFormat, "dd") {
+			mediaDate = d.Format(replacer.Replace(params.DateFormat))
+		}
+
 		// Read Original file from device
 
-		readfile, err := device.OpenRead("/sdcard/DCIM/Camera/" + entries.Entry().Name)
+		readfile, err := device.OpenRead(file.path)
 		if err != nil {
-			result.Errors = append(result.Errors, err)
-			result.FilesNotImported = append(result.FilesNotImported, entries.Entry().Name)
-			return &result, nil //nolint
+			inlineCounter.SetFailure(err, file.name)
+			continue
 		}
 
 		bar, dayFolder, err := prepare(
 			params.Output,
-			entries.Entry().Name,
-			deviceInfo.Product,
+			file.name,
+			deviceName,
 			mediaDate,
 			params.Sort,
 			readfile,
 			progressBar,
 		)
+		readfile.Close()
 		if err != nil {
-			result.Errors = append(result.Errors, err)
-			result.FilesNotImported = append(result.FilesNotImported, entries.Entry().Name)
-			return &result, nil //nolint
-		}
-
-		// Add 1 to queue for concurrency
-		wg.Add(1)
-
-		if entries.Entry().Name == "." || entries.Entry().Name == ".." {
+			inlineCounter.SetFailure(err, file.name)
 			continue
 		}
 
-		if _, err := os.Stat(filepath.Join(dayFolder, "videos")); os.IsNotExist(err) {
-			mkdirerr := os.MkdirAll(filepath.Join(dayFolder, "videos"), 0o755)
-			if mkdirerr != nil {
-				result.Errors = append(result.Errors, mkdirerr)
-				result.FilesNotImported = append(result.FilesNotImported, entries.Entry().Name)
-				return &result, nil //nolint
-			}
+		folder := filepath.Join(dayFolder, "photos")
+		filename, group := pixelNameSort(file.name)
+		if group == "" {
+			group = burstGroup(file.name)
+		}
+		// bursts have their own folder, away from any raw
+		writesSidecar := file.ftype.Type == Video || utils.WritesSidecar(isRaw, params.RawMode.Alongside(isPaired) && group == "")
+		switch {
+		case file.ftype.Type == Video:
+			folder = filepath.Join(dayFolder, "videos")
+		case isRaw:
+			folder = params.RawMode.RawFolder(folder)
+		case group != "":
+			folder = filepath.Join(folder, group)
 		}
-		if _, err := os.Stat(filepath.Join(dayFolder, "photos")); os.IsNotExist(err) {
-			mkdirerr := os.MkdirAll(filepath.Join(dayFolder, "photos"), 0o755)
+		if _, err := os.Stat(folder); os.IsNotExist(err) {
+			mkdirerr := os.MkdirAll(folder, 0o755)
 			if mkdirerr != nil {
-				result.Errors = append(result.Errors, mkdirerr)
-				result.FilesNotImported = append(result.FilesNotImported, entries.Entry().Name)
-				return &result, nil //nolint
+				bar.Abort(true)
+				inlineCounter.SetFailure(mkdirerr, file.name)
+				continue
 			}
 		}
 
-		localPath := ""
-		if strings.HasSuffix(strings.ToLower(entries.Entry().Name), ".mp4") {
-			localPath = filepath.Join(dayFolder, "videos", entries.Entry().Name)
+		localPath, renamed := claimed.claim(filepath.Join(folder, filename))
+		if renamed {
+			// still imported, only under another name
+			color.Yellow(">> %s", mErrors.ErrNameConflict(file.path, filepath.Base(localPath)).Error())
 		}
 
-		filename, folder := pixelNameSort(entries.Entry().Name)
-		if folder != "" {
-			if _, err := os.Stat(filepath.Join(dayFolder, "photos", folder)); os.IsNotExist(err) {
-				mkdirerr := os.MkdirAll(filepath.Join(dayFolder, "photos", folder), 0o755)
-				if mkdirerr != nil {
-					result.Errors = append(result.Errors, mkdirerr)
-					result.FilesNotImported = append(result.FilesNotImported, entries.Entry().Name)
-					return &result, nil //nolint
-				}
-			}
-
-			localPath = filepath.Join(dayFolder, "photos", folder, filename)
-		} else if strings.HasSuffix(strings.ToLower(entries.Entry().Name), ".jpg") {
-			localPath = filepath.Join(dayFolder, "photos", entries.Entry().Name)
-		}
+		// Add 1 to queue for concurrency
+		wg.Add(1)
 
-		go func(filename, localPath string, bar *mpb.Bar) {
+		go func(file deviceFile, localPath string, d time.Time, writesSidecar bool, bar *mpb.Bar) {
 			defer wg.Done()
-			readfile, err = device.OpenRead("/sdcard/DCIM/Camera/" + filename)
+			readfile, err := device.OpenRead(file.path)
 			if err != nil {
-				inlineCounter.SetFailure(err, filename)
+				bar.Abort(false)
+				inlineCounter.SetFailure(err, file.name)
 				return
 			}
 			defer readfile.Close()
 			outFile, err := os.Create(localPath)
 			if err != nil {
-				inlineCounter.SetFailure(err, filename)
+				bar.Abort(false)
+				inlineCounter.SetFailure(err, file.name)
 				return
 			}
-			defer outFile.Close()
 
 			proxyReader := bar.ProxyReader(readfile)
 			defer proxyReader.Close()
 
 			_, err = io.Copy(outFile, proxyReader)
+			outFile.Close()
 			if err != nil {
 				inlineCounter.SetFailure(err, localPath)
 				return
 			}
+			_ = os.Chtimes(localPath, d, d)
 			inlineCounter.SetSuccess()
-		}(entries.Entry().Name, localPath, bar)
+			if (params.Sidecars || file.ftype.Type == RawPhoto) && writesSidecar {
+				sidecar := utils.XMP{Model: deviceInfo.Model, SerialNumber: deviceInfo.Serial, CreateDate: d}
+				if err := utils.WriteSidecar(locationService, localPath, sidecar); err != nil {
+					inlineCounter.SetFailure(err, utils.SidecarPath(file.name))
+				}
+			}
+		}(file, localPath, d, writesSidecar, bar)
 	}
 
 	wg.Wait()
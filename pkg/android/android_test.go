@@ -0,0 +1,36 @@
+package android
+
+import (
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimedPaths(t *testing.T) {
+	claimed := claimedPaths{}
+	folder := filepath.Join("out", "05-08-2023", "Pixel 7", "photos")
+	saved := []string{}
+	// an edited copy in Pictures keeps the name of the original in DCIM/Camera
+	for _, name := range []string{
+		"/sdcard/DCIM/Camera/IMG_20230805_123456.jpg",
+		"/sdcard/Pictures/IMG_20230805_123456.jpg",
+		"/sdcard/Download/img_20230805_123456.JPG",
+		"/sdcard/DCIM/Camera/IMG_20230805_123457.jpg",
+	} {
+		localPath, _ := claimed.claim(filepath.Join(folder, path.Base(name)))
+		saved = append(saved, filepath.Base(localPath))
+	}
+	require.Equal(t, []string{
+		"IMG_20230805_123456.jpg",
+		"IMG_20230805_123456_2.jpg",
+		"img_20230805_123456_3.JPG",
+		"IMG_20230805_123457.jpg",
+	}, saved)
+
+	_, renamed := claimed.claim(filepath.Join(folder, "IMG_20230805_123456.jpg"))
+	require.True(t, renamed)
+	_, renamed = claimed.claim(filepath.Join(folder, "..", "videos", "IMG_20230805_123456.jpg"))
+	require.False(t, renamed)
+}
@@ -0,0 +1,91 @@
+package android
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var fileTypes = []FileTypeMatch{
+	{
+		Regex: regexp.MustCompile(`(?i)\.(jpe?g|heic|heif|webp|png)$`),
+		Type:  Photo,
+	},
+	{
+		Regex: regexp.MustCompile(`(?i)\.dng$`),
+		Type:  RawPhoto,
+	},
+	{
+		Regex: regexp.MustCompile(`(?i)\.(mp4|3gp)$`),
+		Type:  Video,
+	},
+}
+
+func fileTypeOf(name string) (FileTypeMatch, bool) {
+	for _, ftype := range fileTypes {
+		if ftype.Regex.MatchString(name) {
+			return ftype, true
+		}
+	}
+	return FileTypeMatch{}, false
+}
+
+/*
+File names carrying the capture time, the file time on the phone being when it was last copied or edited:
+  - Pixel, in UTC: PXL_20211212_121243677.jpg, PXL_20211212_121243677.RAW-02.ORIGINAL.dng
+  - Samsung: 20230805_123456.jpg, Screenshot_20230805-123456_Chrome.jpg, Screen_Recording_20230805-123456_Chrome.mp4
+  - OnePlus and most others: IMG_20230805_123456.jpg, VID_20230805_123456.mp4, IMG20230805123456.jpg
+  - WhatsApp, the day only: IMG-20230805-WA0001.jpg
+
+Every other name is in local time.
+*/
+var timestampedNames = []struct {
+	regex  *regexp.Regexp
+	layout string
+	utc    bool
+}{
+	{regexp.MustCompile(`^PXL_(20\d{6}_\d{6})`), "20060102150405", true},
+	{regexp.MustCompile(`(?:^|\D)(20\d{6}[_-]?\d{6})`), "20060102150405", false},
+	{regexp.MustCompile(`(?:^|\D)(20\d{6})-WA\d+`), "20060102", false},
+}
+
+// captureTime reads the capture time from a timestamped file name, in local time
+func captureTime(name string) (time.Time, bool) {
+	for _, named := range timestampedNames {
+		parts := named.regex.FindStringSubmatch(name)
+		if parts == nil {
+			continue
+		}
+		value := strings.NewReplacer("_", "", "-", "").Replace(parts[1])
+		location := time.Local
+		if named.utc {
+			location = time.UTC
+		}
+		t, err := time.ParseInLocation(named.layout, value, location)
+		if err != nil {
+			continue
+		}
+		return t.Local(), true
+	}
+	return time.Time{}, false
+}
+
+// pairKey is what a raw and its JPEG share: the name without extension, or without the Pixel .RAW-01.COVER.jpg suffix
+func pairKey(name string) string {
+	if i := strings.Index(name, ".RAW-"); i >= 0 {
+		return name[:i]
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// bursts are kept together like Pixel motion photos: 20230805_123456_001.jpg (Samsung), IMG_20230805_123456_BURST001_COVER.jpg (OnePlus)
+var burstName = regexp.MustCompile(`^(.*\d{8}_\d{6})_(BURST)?\d{3}(_COVER)?\.[A-Za-z]+$`)
+
+func burstGroup(filename string) string {
+	parts := burstName.FindStringSubmatch(filename)
+	if parts == nil {
+		return ""
+	}
+	return parts[1]
+}
@@ -0,0 +1,78 @@
+package android
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTypeOf(t *testing.T) {
+	for name, expected := range map[string]FileType{
+		"PXL_20211212_121243677.jpg":                  Photo,
+		"20230805_123456.heic":                        Photo,
+		"IMG_20230805_123456.HEIF":                    Photo,
+		"Screenshot_20230805-123456_Chrome.webp":      Photo,
+		"PXL_20211212_121243677.RAW-02.ORIGINAL.dng":  RawPhoto,
+		"Screen_Recording_20230805-123456_Chrome.mp4": Video,
+		"VID-20230805-WA0001.3gp":                     Video,
+	} {
+		ftype, ok := fileTypeOf(name)
+		require.True(t, ok, name)
+		require.Equal(t, expected, ftype.Type, name)
+	}
+	_, ok := fileTypeOf("notes.txt")
+	require.False(t, ok)
+}
+
+func TestCaptureTime(t *testing.T) {
+	// away from UTC, Pixel names are the only ones not in local time
+	local := time.Local
+	time.Local = time.FixedZone("UTC+2", 2*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	for name, expected := range map[string]time.Time{
+		"PXL_20211212_121243677.MOTION-01.COVER.jpg": time.Date(2021, 12, 12, 14, 12, 43, 0, time.Local),
+		"20230805_123456.jpg":                        time.Date(2023, 8, 5, 12, 34, 56, 0, time.Local),
+		"Screenshot_20230805-123456_Chrome.jpg":      time.Date(2023, 8, 5, 12, 34, 56, 0, time.Local),
+		"IMG20230805123456.jpg":                      time.Date(2023, 8, 5, 12, 34, 56, 0, time.Local),
+		"VID_20230805_123456.mp4":                    time.Date(2023, 8, 5, 12, 34, 56, 0, time.Local),
+		"IMG-20230805-WA0001.jpg":                    time.Date(2023, 8, 5, 0, 0, 0, 0, time.Local),
+	} {
+		captured, found := captureTime(name)
+		require.True(t, found, name)
+		require.Equal(t, expected, captured, name)
+	}
+	_, found := captureTime("DSC_0001.JPG")
+	require.False(t, found)
+}
+
+func TestGroups(t *testing.T) {
+	require.Equal(t, "20230805_123456", burstGroup("20230805_123456_001.jpg"))
+	require.Equal(t, "IMG_20230805_123456", burstGroup("IMG_20230805_123456_BURST001_COVER.jpg"))
+	require.Empty(t, burstGroup("PXL_20211212_121243677.jpg"))
+	require.Empty(t, burstGroup("20230805_123456(1).jpg"))
+
+	_, group := pixelNameSort("PXL_20211212_121243677.MOTION-02.ORIGINAL.jpg")
+	require.Equal(t, "PXL_20211212_121243677", group)
+}
+
+func TestPairedPhotos(t *testing.T) {
+	files := []deviceFile{}
+	for _, name := range []string{
+		"/sdcard/DCIM/Camera/PXL_20211212_121243677.RAW-01.COVER.jpg",
+		"/sdcard/DCIM/Camera/PXL_20211212_121243677.RAW-02.ORIGINAL.dng",
+		"/sdcard/DCIM/Camera/20230805_123456.dng",
+		"/sdcard/Pictures/20230805_123456.jpg",
+	} {
+		ftype, ok := fileTypeOf(name)
+		require.True(t, ok)
+		files = append(files, deviceFile{path: name, name: path.Base(name), ftype: ftype})
+	}
+
+	paired := pairedPhotos(files)
+	require.True(t, paired["/sdcard/DCIM/Camera/PXL_20211212_121243677"])
+	// same name in another folder is not a pair
+	require.False(t, paired["/sdcard/DCIM/Camera/20230805_123456"])
+}
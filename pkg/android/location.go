@@ -13,9 +13,9 @@ var ffprobe = utils.NewFFprobe(nil)
 
 func (LocationService) GetLocation(path string) (*utils.Location, error) {
 	switch true {
-	case strings.Contains(strings.ToLower(path), ".mp4"):
+	case strings.Contains(strings.ToLower(path), ".mp4") || strings.Contains(strings.ToLower(path), ".3gp"):
 		return ffprobe.GPSLocation(path)
-	case strings.Contains(strings.ToLower(path), ".jpg"):
+	case strings.Contains(strings.ToLower(path), ".jpg") || strings.Contains(strings.ToLower(path), ".jpeg") || strings.Contains(strings.ToLower(path), ".dng"):
 		return utils.LocationFromEXIF(path)
 	default:
 		return nil, mErrors.ErrInvalidFile
@@ -0,0 +1,19 @@
+package android
+
+import "regexp"
+
+type FileType string
+
+const (
+	Video    FileType = "video"
+	Photo    FileType = "photo"
+	RawPhoto FileType = "dng"
+)
+
+type FileTypeMatch struct {
+	Regex *regexp.Regexp
+	Type  FileType
+}
+
+// DefaultPaths are the folders under /sdcard imported unless others are configured
+var DefaultPaths = []string{"DCIM/Camera", "Pictures", "Movies", "Download"}
@@ -0,0 +1,228 @@
+package dji
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+/*
+DeviceName turns a model as written by the device into the product name: model codes (FC3170) go through
+DeviceNames, also when part of a longer string such as an encoder name. Newer devices write the product
+name itself (DJI Mini 4 Pro), which is kept without the DJI prefix.
+*/
+func DeviceName(model string) (string, bool) {
+	model = strings.TrimSpace(strings.Trim(model, "\x00"))
+	if model == "" {
+		return "", false
+	}
+	if name, found := DeviceNames[model]; found {
+		return name, true
+	}
+
+	// longest codes first, FC300SE before FC300S
+	codes := make([]string, 0, len(DeviceNames))
+	for code := range DeviceNames {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return len(codes[i]) > len(codes[j]) })
+	for _, code := range codes {
+		if strings.Contains(model, code) {
+			return DeviceNames[code], true
+		}
+	}
+
+	if strings.HasPrefix(model, "DJI ") {
+		return strings.TrimPrefix(model, "DJI "), true
+	}
+	for _, name := range DeviceNames {
+		if strings.EqualFold(model, name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// getDeviceName identifies the device from a photo's EXIF, a video's metadata or the SRT of a video
+func getDeviceName(path string) (string, bool) {
+	ext := strings.ToUpper(filepath.Ext(path))
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	candidates := []func() (string, error){}
+	switch ext {
+	case ".JPG", ".DNG":
+		candidates = append(candidates, func() (string, error) { return getDeviceNameFromPhoto(path) })
+	case ".MP4", ".LRF":
+		candidates = append(candidates,
+			func() (string, error) { return getDeviceNameFromVideo(path) },
+			func() (string, error) { return getDeviceNameFromSRT(base + ".SRT") },
+		)
+	case ".SRT":
+		candidates = append(candidates,
+			func() (string, error) { return getDeviceNameFromSRT(path) },
+			func() (string, error) { return getDeviceNameFromVideo(base + ".MP4") },
+		)
+	}
+	for _, candidate := range candidates {
+		model, err := candidate()
+		if err != nil {
+			continue
+		}
+		if name, found := DeviceName(model); found {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func getDeviceNameFromPhoto(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	exifData, err := exif.Decode(f)
+	if err != nil {
+		return "", err
+	}
+
+	camModel, err := exifData.Get(exif.Model)
+	if err != nil {
+		return "", err
+	}
+	s, err := camModel.StringVal()
+	if err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// the SRT header of some firmwares carries the model code, the first entries are enough
+func getDeviceNameFromSRT(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	content, err := ioutil.ReadAll(io.LimitReader(f, 2048))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if _, found := DeviceName(line); found {
+			return line, nil
+		}
+	}
+	return "", mErrors.ErrNotFound("model in " + filepath.Base(path))
+}
+
+// MP4 boxes holding other boxes on the way to the metadata, meta has 4 bytes of version and flags first
+var videoContainers = map[string]int{"moov": 0, "udta": 0, "trak": 0, "mdia": 0, "meta": 4, "ilst": 0}
+
+/*
+getDeviceNameFromVideo looks for the model in the QuickTime user data (©mdl, ©mak, ©enc, ©too, ©swr), the iTunes
+style metadata list and the handler names of the tracks, which DJI sets to the encoder, eg: DJI.AVC.
+*/
+func getDeviceNameFromVideo(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	values := []string{}
+	if err := readVideoBoxes(f, 0, stat.Size(), "", &values); err != nil {
+		return "", err
+	}
+	for _, value := range values {
+		if _, found := DeviceName(value); found {
+			return value, nil
+		}
+	}
+	return "", mErrors.ErrNotFound("model in " + filepath.Base(path))
+}
+
+func readVideoBoxes(r io.ReaderAt, start, end int64, parent string, values *[]string) error {
+	header := make([]byte, 16)
+	for start+8 <= end {
+		if _, err := r.ReadAt(header[:8], start); err != nil {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header))
+		kind := string(header[4:8])
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			size = end - start
+		case 1:
+			if _, err := r.ReadAt(header[8:16], start+8); err != nil {
+				return err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:]))
+			headerSize = 16
+		}
+		if size < headerSize || start+size > end {
+			return mErrors.ErrInvalidSuppliedData("MP4 box " + kind)
+		}
+
+		payloadStart := start + headerSize
+		if skip, container := videoContainers[kind]; container {
+			if err := readVideoBoxes(r, payloadStart+int64(skip), start+size, kind, values); err != nil {
+				return err
+			}
+		} else if kind == "mdat" || size-headerSize > 4096 {
+			// media and thumbnails, no metadata in there
+		} else {
+			payload := make([]byte, size-headerSize)
+			if _, err := r.ReadAt(payload, payloadStart); err != nil {
+				return err
+			}
+			if value := videoBoxString(kind, parent, payload); value != "" {
+				*values = append(*values, value)
+			}
+		}
+		start += size
+	}
+	return nil
+}
+
+func videoBoxString(kind, parent string, payload []byte) string {
+	switch {
+	case kind == "hdlr" && len(payload) > 24:
+		// version and flags, pre-defined, handler type and 12 reserved bytes, then the name, length prefixed by QuickTime
+		name := payload[24:]
+		if int(name[0]) == len(name)-1 {
+			name = name[1:]
+		}
+		return cString(name)
+	case parent == "ilst" && len(payload) > 16 && string(payload[4:8]) == "data":
+		// a data box: size, "data", type and locale
+		return cString(payload[16:])
+	case parent == "udta" && strings.HasPrefix(kind, "\xa9") && len(payload) > 4:
+		// text length and language, then the text
+		length := int(binary.BigEndian.Uint16(payload))
+		if 4+length > len(payload) {
+			length = len(payload) - 4
+		}
+		return cString(payload[4 : 4+length])
+	}
+	return ""
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
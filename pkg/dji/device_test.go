@@ -0,0 +1,105 @@
+package dji
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func box(kind string, payload ...[]byte) []byte {
+	b := make([]byte, 8)
+	copy(b[4:], kind)
+	for _, p := range payload {
+		b = append(b, p...)
+	}
+	binary.BigEndian.PutUint32(b, uint32(len(b)))
+	return b
+}
+
+func udtaText(text string) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint16(b, uint16(len(text)))
+	return append(b, text...)
+}
+
+func hdlr(name string) []byte {
+	return append(append(make([]byte, 8), "vide"+string(make([]byte, 12))...), name+"\x00"...)
+}
+
+// tiffWithModel is the smallest TIFF carrying an EXIF Model tag, enough for exif.Decode
+func tiffWithModel(model string) []byte {
+	value := model + "\x00"
+	b := []byte{'I', 'I', 42, 0, 8, 0, 0, 0, 1, 0}
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(entry, 0x0110)
+	binary.LittleEndian.PutUint16(entry[2:], 2)
+	binary.LittleEndian.PutUint32(entry[4:], uint32(len(value)))
+	binary.LittleEndian.PutUint32(entry[8:], 8+2+12+4)
+	b = append(b, entry...)
+	b = append(b, 0, 0, 0, 0)
+	return append(b, value...)
+}
+
+func write(t *testing.T, folder, name string, content []byte) string {
+	path := filepath.Join(folder, name)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return path
+}
+
+func TestDeviceName(t *testing.T) {
+	for model, name := range map[string]string{
+		"FC3170":          "Mavic Air 2",
+		"FC300SE":         "Phantom 3 Pro",
+		"FC2204":          "Mavic 2 Zoom",
+		"DJI FC3582":      "Mini 3 Pro",
+		"DJI Mini 4 Pro":  "Mini 4 Pro",
+		"mavic air 2":     "Mavic Air 2",
+		"FC3170\x00\x00 ": "Mavic Air 2",
+	} {
+		got, found := DeviceName(model)
+		require.True(t, found, model)
+		require.Equal(t, name, got, model)
+	}
+	for _, model := range []string{"", "DJI.AVC", "VideoHandler", "Canon EOS R5"} {
+		_, found := DeviceName(model)
+		require.False(t, found, model)
+	}
+}
+
+func TestGetDeviceName(t *testing.T) {
+	folder := t.TempDir()
+
+	photo := write(t, folder, "DJI_0001.JPG", tiffWithModel("FC3170"))
+	name, found := getDeviceName(photo)
+	require.True(t, found)
+	require.Equal(t, "Mavic Air 2", name)
+
+	moov := box("moov",
+		box("trak", box("mdia", box("hdlr", hdlr("DJI.AVC")))),
+		box("udta", box("\xa9mdl", udtaText("FC3582"))),
+	)
+	video := write(t, folder, "DJI_0002.MP4", append(append(box("ftyp", []byte("isom")), moov...), box("mdat", []byte("frames"))...))
+	name, found = getDeviceName(video)
+	require.True(t, found)
+	require.Equal(t, "Mini 3 Pro", name)
+
+	// the SRT is sorted with its video
+	write(t, folder, "DJI_0002.SRT", []byte("1\n00:00:00,000 --> 00:00:00,033\n[iso : 100]\n"))
+	name, found = getDeviceName(filepath.Join(folder, "DJI_0002.SRT"))
+	require.True(t, found)
+	require.Equal(t, "Mini 3 Pro", name)
+
+	// no metadata in the video, the SRT header names the model
+	ilst := box("moov", box("udta", box("meta", make([]byte, 4), box("ilst", box("\xa9too", box("data", make([]byte, 8), []byte("Lavf58.76.100")))))))
+	write(t, folder, "DJI_0003.MP4", append(box("ftyp", []byte("isom")), ilst...))
+	write(t, folder, "DJI_0003.SRT", []byte("FC7303\n1\n00:00:00,000 --> 00:00:00,033\n"))
+	name, found = getDeviceName(filepath.Join(folder, "DJI_0003.MP4"))
+	require.True(t, found)
+	require.Equal(t, "Mini 2", name)
+
+	_, found = getDeviceName(write(t, folder, "DJI_0004.MP4", box("ftyp", []byte("isom"))))
+	require.False(t, found)
+}
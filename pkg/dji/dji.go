@@ -15,33 +15,10 @@ import (
 	"github.com/karrick/godirwalk"
 	"github.com/konradit/mmt/pkg/utils"
 	"github.com/minio/minio/pkg/disk"
-	"github.com/rwcarlsen/goexif/exif"
 	"github.com/vbauerster/mpb/v8"
 	"gopkg.in/djherbis/times.v1"
 )
 
-func getDeviceNameFromPhoto(path string) (string, error) { //nolint:unused
-	f, err := os.Open(path)
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
-	exifData, err := exif.Decode(f)
-	if err != nil {
-		return "", err
-	}
-
-	camModel, err := exifData.Get(exif.Model)
-	if err != nil {
-		return "", err
-	}
-	s, err := camModel.StringVal()
-	if err != nil {
-		return "", err
-	}
-	return s, nil
-}
-
 var locationService = LocationService{}
 
 type Entrypoint struct{}
@@ -49,7 +26,8 @@ type Entrypoint struct{}
 func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 	// Tested on Mavic Air 2. Osmo Pocket v1 and Spark specific changes to follow.
 
-	if params.CameraName == "" {
+	knownCamera := params.CameraName != ""
+	if !knownCamera {
 		params.CameraName = "DJI Device"
 	}
 	di, err := disk.GetInfo(params.Input)
@@ -64,7 +42,8 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 		percentage,
 	)
 
-	mediaFolderRegex := regexp.MustCompile(`\d+MEDIA`)
+	// drones and older Osmos use 100MEDIA, Osmo Action 3/4, Pocket 3 and Mini 4 Pro use DJI_001
+	mediaFolderRegex := regexp.MustCompile(`^(\d+MEDIA|DJI_\d+)$`)
 
 	root := filepath.Join(params.Input, "DCIM")
 	var result utils.Result
@@ -75,12 +54,20 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 		return &result, nil
 	}
 
+	if !knownCamera {
+		// files that don't name the model, like videos with only a DJI.AVC handler, go with the rest of the card
+		if name, found := getCardDeviceName(root, folders, mediaFolderRegex); found {
+			params.CameraName = name
+		}
+	}
+
 	var wg sync.WaitGroup
 	progressBar := mpb.New(mpb.WithWaitGroup(&wg),
 		mpb.WithWidth(60),
 		mpb.WithRefreshRate(180*time.Millisecond))
 
 	inlineCounter := utils.ResultCounter{}
+	imported := importedMedia{}
 
 	for _, f := range folders {
 		r := mediaFolderRegex.MatchString(f.Name())
@@ -102,6 +89,9 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 						return godirwalk.SkipThis
 					}
 					d := t.ModTime()
+					if captured, found := captureTime(de.Name()); found {
+						d = captured
+					}
 
 					mediaDate := d.Format("02-01-2006")
 					if strings.Contains(params.DateFormat, "yyyy") && strings.Contains(params.DateFormat, "mm") && strings.Contains(params.DateFormat, "dd") {
@@ -119,10 +109,20 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 						return godirwalk.SkipThis
 					}
 
+					if (ftype.Type == Photo || ftype.Type == RawPhoto) && !params.RawMode.KeepFile(osPathname) {
+						return godirwalk.SkipThis
+					}
+
 					wg.Add(1)
 					bar := utils.GetNewBar(progressBar, info.Size(), de.Name(), utils.IoTX)
 
-					dayFolder := utils.GetOrder(params.Sort, locationService, osPathname, params.Output, mediaDate, params.CameraName)
+					cameraName := params.CameraName
+					if !knownCamera {
+						if name, found := getDeviceName(osPathname); found {
+							cameraName = name
+						}
+					}
+					dayFolder := utils.GetOrder(params.Sort, locationService, osPathname, params.Output, mediaDate, cameraName)
 					switch ftype.Type {
 					case Photo:
 						if _, err := os.Stat(filepath.Join(dayFolder, "photos")); os.IsNotExist(err) {
@@ -132,17 +132,21 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 							}
 						}
 
-						go func(filename, osPathname string, bar *mpb.Bar) {
+						go func(filename, osPathname, cameraName string, bar *mpb.Bar) {
 							defer wg.Done()
-							err = utils.CopyFile(osPathname, filepath.Join(dayFolder, "photos", filename), params.BufferSize, bar, d)
+							err := utils.CopyFile(osPathname, filepath.Join(dayFolder, "photos", filename), params.BufferSize, bar, d)
 							if err != nil {
 								bar.EwmaSetCurrent(info.Size(), 1*time.Millisecond)
 								bar.EwmaIncrInt64(info.Size(), 1*time.Millisecond)
 								inlineCounter.SetFailure(err, filename)
 							} else {
 								inlineCounter.SetSuccess()
+								imported.add(filepath.Join(dayFolder, "photos", filename), Photo, d)
+								if params.Sidecars && params.RawMode.WritesSidecarFile(osPathname) {
+									writeSidecar(filepath.Join(dayFolder, "photos", filename), osPathname, cameraName, d, &inlineCounter)
+								}
 							}
-						}(de.Name(), osPathname, bar)
+						}(de.Name(), osPathname, cameraName, bar)
 
 					case Video:
 						if _, err := os.Stat(filepath.Join(dayFolder, "videos")); os.IsNotExist(err) {
@@ -152,17 +156,21 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 							}
 						}
 
-						go func(filename, osPathname string, bar *mpb.Bar) {
+						go func(filename, osPathname, cameraName string, bar *mpb.Bar) {
 							defer wg.Done()
-							err = utils.CopyFile(osPathname, filepath.Join(dayFolder, "videos", filename), params.BufferSize, bar, d)
+							err := utils.CopyFile(osPathname, filepath.Join(dayFolder, "videos", filename), params.BufferSize, bar, d)
 							if err != nil {
 								bar.EwmaSetCurrent(info.Size(), 1*time.Millisecond)
 								bar.EwmaIncrInt64(info.Size(), 1*time.Millisecond)
 								inlineCounter.SetFailure(err, filename)
 							} else {
 								inlineCounter.SetSuccess()
+								imported.add(filepath.Join(dayFolder, "videos", filename), Video, d)
+								if params.Sidecars {
+									writeSidecar(filepath.Join(dayFolder, "videos", filename), osPathname, cameraName, d, &inlineCounter)
+								}
 							}
-						}(de.Name(), osPathname, bar)
+						}(de.Name(), osPathname, cameraName, bar)
 					case Subtitle:
 						extraPath := srtFolderFromConfig()
 						if params.SkipAuxiliaryFiles {
@@ -180,7 +188,7 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 
 						go func(filename, osPathname string, bar *mpb.Bar) {
 							defer wg.Done()
-							err = utils.CopyFile(osPathname, filepath.Join(dayFolder, "videos", extraPath, filename), params.BufferSize, bar, d)
+							err := utils.CopyFile(osPathname, filepath.Join(dayFolder, "videos", extraPath, filename), params.BufferSize, bar, d)
 							if err != nil {
 								bar.EwmaSetCurrent(info.Size(), 1*time.Millisecond)
 								bar.EwmaIncrInt64(info.Size(), 1*time.Millisecond)
@@ -190,8 +198,36 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 							}
 						}(de.Name(), osPathname, bar)
 					case RawPhoto:
-						if _, err := os.Stat(filepath.Join(dayFolder, "photos/raw")); os.IsNotExist(err) {
-							mkdirerr := os.MkdirAll(filepath.Join(dayFolder, "photos/raw"), 0o755)
+						rawFolder := params.RawMode.RawFolder(filepath.Join(dayFolder, "photos"))
+						if _, err := os.Stat(rawFolder); os.IsNotExist(err) {
+							mkdirerr := os.MkdirAll(rawFolder, 0o755)
+							if mkdirerr != nil {
+								log.Fatal(mkdirerr.Error())
+							}
+						}
+
+						go func(filename, osPathname, cameraName string, bar *mpb.Bar) {
+							defer wg.Done()
+							err := utils.CopyFile(osPathname, filepath.Join(rawFolder, filename), params.BufferSize, bar, d)
+							if err != nil {
+								bar.EwmaSetCurrent(info.Size(), 1*time.Millisecond)
+								bar.EwmaIncrInt64(info.Size(), 1*time.Millisecond)
+								inlineCounter.SetFailure(err, filename)
+							} else {
+								inlineCounter.SetSuccess()
+								writeSidecar(filepath.Join(rawFolder, filename), osPathname, cameraName, d, &inlineCounter)
+							}
+						}(de.Name(), osPathname, cameraName, bar)
+					case LowResolutionVideo:
+						if params.SkipAuxiliaryFiles {
+							wg.Done()
+							bar.Abort(true)
+							break
+						}
+
+						// named after the video like GoPro LRVs, so proxy sees the video has one
+						if _, err := os.Stat(filepath.Join(dayFolder, "videos", "proxy")); os.IsNotExist(err) {
+							mkdirerr := os.MkdirAll(filepath.Join(dayFolder, "videos", "proxy"), 0o755)
 							if mkdirerr != nil {
 								log.Fatal(mkdirerr.Error())
 							}
@@ -199,7 +235,7 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 
 						go func(filename, osPathname string, bar *mpb.Bar) {
 							defer wg.Done()
-							err = utils.CopyFile(osPathname, filepath.Join(dayFolder, "photos/raw", filename), params.BufferSize, bar, d)
+							err := utils.CopyFile(osPathname, filepath.Join(dayFolder, "videos", "proxy", strings.TrimSuffix(filename, filepath.Ext(filename))+".MP4"), params.BufferSize, bar, d)
 							if err != nil {
 								bar.EwmaSetCurrent(info.Size(), 1*time.Millisecond)
 								bar.EwmaIncrInt64(info.Size(), 1*time.Millisecond)
@@ -209,6 +245,25 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 							}
 						}(de.Name(), osPathname, bar)
 					case PanoramaIndex:
+						// indexes outside of a PANORAMA set are kept with the panoramas
+						if _, err := os.Stat(filepath.Join(dayFolder, "panoramas")); os.IsNotExist(err) {
+							mkdirerr := os.MkdirAll(filepath.Join(dayFolder, "panoramas"), 0o755)
+							if mkdirerr != nil {
+								log.Fatal(mkdirerr.Error())
+							}
+						}
+
+						go func(filename, osPathname string, bar *mpb.Bar) {
+							defer wg.Done()
+							err := utils.CopyFile(osPathname, filepath.Join(dayFolder, "panoramas", filename), params.BufferSize, bar, d)
+							if err != nil {
+								bar.EwmaSetCurrent(info.Size(), 1*time.Millisecond)
+								bar.EwmaIncrInt64(info.Size(), 1*time.Millisecond)
+								inlineCounter.SetFailure(err, filename)
+							} else {
+								inlineCounter.SetSuccess()
+							}
+						}(de.Name(), osPathname, bar)
 					}
 				}
 
@@ -221,12 +276,117 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 		}
 	}
 
+	sets, err := findSequenceSets(root)
+	if err != nil {
+		inlineCounter.SetFailure(err, "")
+	}
+	setFolders := map[string]*sequenceSet{}
+	for _, set := range sets {
+		if set.first.Before(params.DateRange[0]) || set.first.After(params.DateRange[1]) {
+			continue
+		}
+		color.Green("Looking at %s %s", set.kind, set.name)
+
+		mediaDate := set.first.Format("02-01-2006")
+		if strings.Contains(params.DateFormat, "yyyy") && strings.Contains(params.DateFormat, "mm") && strings.Contains(params.DateFormat, "dd") {
+			mediaDate = set.first.Format(utils.DateFormatReplacer.Replace(params.DateFormat))
+		}
+		cameraName := params.CameraName
+		if !knownCamera {
+			if name, found := getDeviceName(set.frames[0]); found {
+				cameraName = name
+			}
+		}
+		dayFolder := utils.GetOrder(params.Sort, locationService, set.frames[0], params.Output, mediaDate, cameraName)
+		setFolder := filepath.Join(dayFolder, set.slug(), set.name)
+		if _, err := os.Stat(setFolder); os.IsNotExist(err) {
+			mkdirerr := os.MkdirAll(setFolder, 0o755)
+			if mkdirerr != nil {
+				log.Fatal(mkdirerr.Error())
+			}
+		}
+		setFolders[setFolder] = set
+
+		files := set.frames
+		if set.index != "" {
+			files = append(files, set.index)
+		}
+		for _, osPathname := range files {
+			frame := osPathname != set.index
+			if frame && !params.RawMode.KeepFile(osPathname) {
+				continue
+			}
+			t, err := times.Stat(osPathname)
+			if err != nil {
+				inlineCounter.SetFailure(err, filepath.Base(osPathname))
+				continue
+			}
+			info, err := os.Stat(osPathname)
+			if err != nil {
+				inlineCounter.SetFailure(err, filepath.Base(osPathname))
+				continue
+			}
+
+			wg.Add(1)
+			bar := utils.GetNewBar(progressBar, info.Size(), filepath.Base(osPathname), utils.IoTX)
+			go func(filename, osPathname string, frame bool, size int64, d time.Time, bar *mpb.Bar) {
+				defer wg.Done()
+				err := utils.CopyFile(osPathname, filepath.Join(setFolder, filename), params.BufferSize, bar, d)
+				if err != nil {
+					bar.EwmaSetCurrent(size, 1*time.Millisecond)
+					bar.EwmaIncrInt64(size, 1*time.Millisecond)
+					inlineCounter.SetFailure(err, filename)
+				} else {
+					inlineCounter.SetSuccess()
+					if frame && writesFrameSidecar(osPathname, params.RawMode, params.Sidecars) {
+						writeSidecar(filepath.Join(setFolder, filename), osPathname, cameraName, d, &inlineCounter)
+					}
+				}
+			}(filepath.Base(osPathname), osPathname, frame, info.Size(), t.ModTime(), bar)
+		}
+	}
+
 	wg.Wait()
 	progressBar.Shutdown()
 
+	finishSequences(setFolders, &imported, &inlineCounter)
+
 	result.Errors = append(result.Errors, inlineCounter.Get().Errors...)
 	result.FilesImported += inlineCounter.Get().FilesImported
 	result.FilesNotImported = append(result.FilesNotImported, inlineCounter.Get().FilesNotImported...)
 
 	return &result, nil
 }
+
+// writeSidecar describes an imported file with an XMP sidecar next to it, located from the card as the SRT stays behind
+func writeSidecar(path, source, cameraName string, d time.Time, counter *utils.ResultCounter) {
+	sidecar := utils.XMP{Make: "DJI", Model: cameraName, CreateDate: d}
+	if location, err := locationService.GetLocation(source); err == nil {
+		sidecar.Location = location
+	}
+	if err := utils.WriteSidecar(locationService, path, sidecar); err != nil {
+		counter.SetFailure(err, utils.SidecarPath(filepath.Base(path)))
+	}
+}
+
+// getCardDeviceName identifies the device from the first file of the media folders that names it
+func getCardDeviceName(root string, folders []os.FileInfo, mediaFolderRegex *regexp.Regexp) (string, bool) {
+	for _, f := range folders {
+		if !mediaFolderRegex.MatchString(f.Name()) {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(root, f.Name()))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if name, found := getDeviceName(filepath.Join(root, f.Name(), entry.Name())); found {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,133 @@
+package dji
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureTime(t *testing.T) {
+	captured, found := captureTime("DJI_20230805123456_0001_D.MP4")
+	require.True(t, found)
+	require.Equal(t, time.Date(2023, 8, 5, 12, 34, 56, 0, time.Local), captured)
+
+	_, found = captureTime("DJI_20230805123456_0001_D.LRF")
+	require.True(t, found)
+	_, found = captureTime("DJI_0001.MP4")
+	require.False(t, found)
+}
+
+func TestImportLRF(t *testing.T) {
+	card := t.TempDir()
+	media := filepath.Join(card, "DCIM", "DJI_001")
+	// the card was written a day later, the name has the capture time
+	written := time.Date(2023, 8, 6, 9, 0, 0, 0, time.Local)
+	for _, name := range []string{"DJI_20230805123456_0001_D.MP4", "DJI_20230805123456_0001_D.LRF", "DJI_20230805123456_0001_D.SRT"} {
+		writeCardFile(t, filepath.Join(media, name), written)
+	}
+
+	for _, skipAux := range []bool{false, true} {
+		output := t.TempDir()
+		result, err := Entrypoint{}.Import(utils.ImportParams{
+			Input:              card,
+			Output:             output,
+			DateFormat:         "dd-mm-yyyy",
+			DateRange:          []time.Time{time.Date(2023, 8, 5, 0, 0, 0, 0, time.Local), time.Date(2023, 8, 5, 23, 59, 59, 0, time.Local)},
+			SkipAuxiliaryFiles: skipAux,
+			BufferSize:         1000,
+		})
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+
+		videos := filepath.Join(output, "05-08-2023", "videos")
+		require.FileExists(t, filepath.Join(videos, "DJI_20230805123456_0001_D.MP4"))
+		proxy := filepath.Join(videos, "proxy", "DJI_20230805123456_0001_D.MP4")
+		if skipAux {
+			require.Equal(t, 1, result.FilesImported)
+			require.NoFileExists(t, proxy)
+			continue
+		}
+		require.Equal(t, 3, result.FilesImported)
+		require.FileExists(t, proxy)
+		content, err := os.ReadFile(proxy)
+		require.NoError(t, err)
+		require.Equal(t, "DJI_20230805123456_0001_D.LRF", string(content))
+	}
+}
+
+func TestImportCardDevice(t *testing.T) {
+	card := t.TempDir()
+	media := filepath.Join(card, "DCIM", "100MEDIA")
+	written := time.Date(2023, 8, 5, 12, 0, 0, 0, time.Local)
+	writeCardFile(t, filepath.Join(media, "DJI_0001.JPG"), written)
+	writeCardFile(t, filepath.Join(media, "DJI_0002.MP4"), written)
+	// the video only names its encoder, the photo names the drone
+	write(t, media, "DJI_0001.JPG", tiffWithModel("FC3170"))
+	write(t, media, "DJI_0002.MP4", append(box("ftyp", []byte("isom")), box("moov", box("trak", box("mdia", box("hdlr", hdlr("DJI.AVC")))))...))
+	for _, name := range []string{"DJI_0001.JPG", "DJI_0002.MP4"} {
+		require.NoError(t, os.Chtimes(filepath.Join(media, name), written, written))
+	}
+
+	output := t.TempDir()
+	result, err := Entrypoint{}.Import(utils.ImportParams{
+		Input:      card,
+		Output:     output,
+		DateFormat: "dd-mm-yyyy",
+		DateRange:  []time.Time{time.Date(2023, 8, 5, 0, 0, 0, 0, time.Local), time.Date(2023, 8, 5, 23, 59, 59, 0, time.Local)},
+		BufferSize: 1000,
+		Sort:       utils.SortOptions{ByCamera: true},
+	})
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	require.Equal(t, 2, result.FilesImported)
+	require.FileExists(t, filepath.Join(output, "05-08-2023", "Mavic Air 2", "photos", "DJI_0001.JPG"))
+	require.FileExists(t, filepath.Join(output, "05-08-2023", "Mavic Air 2", "videos", "DJI_0002.MP4"))
+}
+
+func TestImportRawPairs(t *testing.T) {
+	card := t.TempDir()
+	media := filepath.Join(card, "DCIM", "100MEDIA")
+	written := time.Date(2023, 8, 5, 12, 0, 0, 0, time.Local)
+	for _, name := range []string{"DJI_0001.JPG", "DJI_0001.DNG", "DJI_0002.JPG", "DJI_0003.DNG"} {
+		writeCardFile(t, filepath.Join(media, name), written)
+	}
+
+	for mode, expected := range map[utils.RawMode][]string{
+		utils.RawSeparate: {"DJI_0001.JPG", "DJI_0002.JPG", "raw/DJI_0001.DNG", "raw/DJI_0001.xmp", "raw/DJI_0003.DNG", "raw/DJI_0003.xmp"},
+		utils.RawPair:     {"DJI_0001.DNG", "DJI_0001.JPG", "DJI_0001.xmp", "DJI_0002.JPG", "DJI_0003.DNG", "DJI_0003.xmp"},
+		utils.RawOnly:     {"DJI_0001.DNG", "DJI_0001.xmp", "DJI_0002.JPG", "DJI_0003.DNG", "DJI_0003.xmp"},
+		utils.JPEGOnly:    {"DJI_0001.JPG", "DJI_0002.JPG", "DJI_0003.DNG", "DJI_0003.xmp"},
+	} {
+		output := t.TempDir()
+		result, err := Entrypoint{}.Import(utils.ImportParams{
+			Input:      card,
+			Output:     output,
+			CameraName: "Mavic 3",
+			DateFormat: "dd-mm-yyyy",
+			DateRange:  []time.Time{time.Date(2023, 8, 5, 0, 0, 0, 0, time.Local), time.Date(2023, 8, 5, 23, 59, 59, 0, time.Local)},
+			BufferSize: 1000,
+			RawMode:    mode,
+		})
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+
+		photos := filepath.Join(output, "05-08-2023", "photos")
+		imported := []string{}
+		require.NoError(t, filepath.Walk(photos, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				relative, _ := filepath.Rel(photos, path)
+				imported = append(imported, filepath.ToSlash(relative))
+			}
+			return err
+		}))
+		require.Equal(t, expected, imported, mode)
+
+		sidecar, err := os.ReadFile(filepath.Join(photos, mode.RawFolder("."), "DJI_0003.xmp"))
+		require.NoError(t, err)
+		require.Contains(t, string(sidecar), `tiff:Model="Mavic 3"`)
+	}
+}
@@ -1,6 +1,9 @@
 package dji
 
-import "regexp"
+import (
+	"regexp"
+	"time"
+)
 
 var fileTypes = []FileTypeMatch{
 	{
@@ -11,6 +14,10 @@ var fileTypes = []FileTypeMatch{
 		Regex: regexp.MustCompile(`\.MP4$`),
 		Type:  Video,
 	},
+	{
+		Regex: regexp.MustCompile(`\.LRF$`),
+		Type:  LowResolutionVideo,
+	},
 	{
 		Regex: regexp.MustCompile(`\.SRT$`),
 		Type:  Subtitle,
@@ -24,3 +31,19 @@ var fileTypes = []FileTypeMatch{
 		Type:  PanoramaIndex,
 	},
 }
+
+// newer devices (Osmo Action 3/4, Osmo Pocket 3, Mini 4, Avata) name files after the capture time: DJI_20230805123456_0001_D.MP4
+var timestampedName = regexp.MustCompile(`^DJI_(\d{14})_\d+_[A-Z]\.[A-Za-z0-9]+$`)
+
+// captureTime reads the local capture time from a timestamped file name
+func captureTime(name string) (time.Time, bool) {
+	parts := timestampedName.FindStringSubmatch(name)
+	if parts == nil {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("20060102150405", parts[1], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -43,6 +43,8 @@ func (LocationService) GetLocation(path string) (*utils.Location, error) {
 	switch true {
 	case strings.Contains(path, ".MP4") || strings.Contains(path, ".SRT"):
 		return fromSRT(path)
+	case strings.Contains(path, ".LRF"):
+		return fromSRT(strings.Replace(path, ".LRF", ".SRT", -1))
 	case strings.Contains(path, ".JPG") || strings.Contains(path, ".DNG"):
 		return utils.LocationFromEXIF(path)
 	default:
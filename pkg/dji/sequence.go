@@ -0,0 +1,200 @@
+package dji
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/konradit/mmt/pkg/utils"
+)
+
+// folders under DCIM holding the source frames of panoramas and hyperlapses, one subfolder per set, eg: PANORAMA/100_0001
+var sequenceRoots = map[string]string{
+	"PANORAMA":   "panorama",
+	"HYPERLAPSE": "hyperlapse",
+}
+
+// how long after the last frame the drone writes the stitched panorama or rendered hyperlapse
+var resultWindows = map[string]time.Duration{
+	"panorama":   2 * time.Minute,
+	"hyperlapse": 10 * time.Minute,
+}
+
+var indexReference = regexp.MustCompile(`(?i)DJI_\d+\.(JPG|MP4)`)
+
+// sequenceSet is a panorama or hyperlapse set of frames found on the card
+type sequenceSet struct {
+	kind   string
+	name   string
+	frames []string
+	index  string
+	first  time.Time
+	last   time.Time
+}
+
+// slug is the folder sets of this kind are kept in under the day folder
+func (s *sequenceSet) slug() string {
+	return s.kind + "s"
+}
+
+func (s *sequenceSet) hasFrame(name string) bool {
+	for _, frame := range s.frames {
+		if strings.EqualFold(filepath.Base(frame), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// findSequenceSets lists the panorama and hyperlapse sets under DCIM, frames sorted by name
+func findSequenceSets(root string) ([]*sequenceSet, error) {
+	sets := []*sequenceSet{}
+	for folder, kind := range sequenceRoots {
+		entries, err := os.ReadDir(filepath.Join(root, folder))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return sets, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			set, err := readSequenceSet(filepath.Join(root, folder, entry.Name()), kind)
+			if err != nil {
+				return sets, err
+			}
+			if len(set.frames) > 0 {
+				sets = append(sets, set)
+			}
+		}
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].first.Before(sets[j].first) })
+	return sets, nil
+}
+
+func readSequenceSet(folder, kind string) (*sequenceSet, error) {
+	set := &sequenceSet{kind: kind, name: filepath.Base(folder)}
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(folder, entry.Name())
+		switch strings.ToUpper(filepath.Ext(entry.Name())) {
+		case ".HTML":
+			set.index = path
+			continue
+		case ".JPG", ".DNG":
+		default:
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		if set.first.IsZero() || info.ModTime().Before(set.first) {
+			set.first = info.ModTime()
+		}
+		if info.ModTime().After(set.last) {
+			set.last = info.ModTime()
+		}
+		set.frames = append(set.frames, path)
+	}
+	sort.Strings(set.frames)
+	return set, nil
+}
+
+/*
+writesFrameSidecar tells whether a frame gets a sidecar in its set folder: raws always do, JPEGs with --xmp unless
+their raw is copied next to them and shares its sidecar.
+*/
+func writesFrameSidecar(path string, mode utils.RawMode, sidecars bool) bool {
+	raw := utils.IsRaw(path)
+	_, paired := utils.RawSibling(path)
+	return (raw || sidecars) && utils.WritesSidecar(raw, paired && mode.Keep(!raw, true))
+}
+
+// importedMedia keeps where photos and videos were copied to, to link sets to their stitched result
+type importedMedia struct {
+	mu    sync.Mutex
+	items []importedItem
+}
+
+type importedItem struct {
+	path string
+	kind FileType
+	time time.Time
+}
+
+func (m *importedMedia) add(path string, kind FileType, t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items = append(m.items, importedItem{path: path, kind: kind, time: t})
+}
+
+/*
+result finds what the drone made out of the set: the file named in the panorama index when there is one,
+otherwise the first photo (panorama) or video (hyperlapse) written between the first frame and shortly after the last.
+*/
+func (m *importedMedia) result(set *sequenceSet) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if set.index != "" {
+		if content, err := os.ReadFile(set.index); err == nil {
+			for _, name := range indexReference.FindAllString(string(content), -1) {
+				if set.hasFrame(name) {
+					continue
+				}
+				for _, item := range m.items {
+					if strings.EqualFold(filepath.Base(item.path), name) {
+						return item.path
+					}
+				}
+			}
+		}
+	}
+
+	kind := Photo
+	if set.kind == "hyperlapse" {
+		kind = Video
+	}
+	best := importedItem{}
+	for _, item := range m.items {
+		if item.kind != kind || item.time.Before(set.first) || item.time.After(set.last.Add(resultWindows[set.kind])) {
+			continue
+		}
+		if best.path == "" || item.time.Before(best.time) {
+			best = item
+		}
+	}
+	return best.path
+}
+
+// finishSequences describes every copied set with sequence.json, linked to its stitched result
+func finishSequences(folders map[string]*sequenceSet, imported *importedMedia, counter *utils.ResultCounter) {
+	for folder, set := range folders {
+		sequence, err := utils.ReadSequence(folder, set.name, set.kind, ".JPG", ".DNG")
+		if err != nil {
+			counter.SetFailure(err, set.name)
+			continue
+		}
+		if result := imported.result(set); result != "" {
+			if relative, err := filepath.Rel(folder, result); err == nil {
+				sequence.Result = filepath.ToSlash(relative)
+			}
+		}
+		if err := sequence.Write(folder); err != nil {
+			counter.SetFailure(err, set.name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package dji
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCardFile(t *testing.T, path string, modTime time.Time) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(filepath.Base(path)), 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func readSequenceFile(t *testing.T, folder string) utils.Sequence {
+	b, err := os.ReadFile(filepath.Join(folder, utils.SequenceFile))
+	require.NoError(t, err)
+	sequence := utils.Sequence{}
+	require.NoError(t, json.Unmarshal(b, &sequence))
+	return sequence
+}
+
+func TestImportSequences(t *testing.T) {
+	card := t.TempDir()
+	dcim := filepath.Join(card, "DCIM")
+	start := time.Date(2023, 6, 10, 12, 0, 0, 0, time.Local)
+
+	for i, name := range []string{"DJI_0001.JPG", "DJI_0002.JPG", "DJI_0003.JPG"} {
+		writeCardFile(t, filepath.Join(dcim, "PANORAMA", "100_0001", name), start.Add(time.Duration(i)*2*time.Second))
+	}
+	writeCardFile(t, filepath.Join(dcim, "100MEDIA", "DJI_0004.JPG"), start.Add(30*time.Second))
+	writeCardFile(t, filepath.Join(dcim, "100MEDIA", "DJI_0005.JPG"), start.Add(40*time.Second))
+
+	hyperlapse := start.Add(time.Hour)
+	for i, name := range []string{"HYPERLAPSE_0001.JPG", "HYPERLAPSE_0002.JPG"} {
+		writeCardFile(t, filepath.Join(dcim, "HYPERLAPSE", "100_0002", name), hyperlapse.Add(time.Duration(i)*5*time.Second))
+	}
+	writeCardFile(t, filepath.Join(dcim, "100MEDIA", "DJI_0006.MP4"), hyperlapse.Add(3*time.Minute))
+
+	// the index names the stitched result, not the first photo after the frames
+	writeCardFile(t, filepath.Join(dcim, "PANORAMA", "100_0003", "DJI_0007.JPG"), start.Add(2*time.Hour))
+	writeCardFile(t, filepath.Join(dcim, "PANORAMA", "100_0003", "pano.html"), start.Add(2*time.Hour))
+	require.NoError(t, os.WriteFile(filepath.Join(dcim, "PANORAMA", "100_0003", "pano.html"), []byte(`<img src="DJI_0007.JPG"><img src="DJI_0009.JPG">`), 0o600))
+	writeCardFile(t, filepath.Join(dcim, "100MEDIA", "DJI_0008.JPG"), start.Add(2*time.Hour+time.Second))
+	writeCardFile(t, filepath.Join(dcim, "100MEDIA", "DJI_0009.JPG"), start.Add(2*time.Hour+time.Minute))
+
+	output := t.TempDir()
+	result, err := Entrypoint{}.Import(utils.ImportParams{
+		Input:      card,
+		Output:     output,
+		DateFormat: "dd-mm-yyyy",
+		DateRange:  []time.Time{start.Add(-time.Hour), start.Add(24 * time.Hour)},
+	})
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	require.Equal(t, 12, result.FilesImported)
+
+	day := filepath.Join(output, start.Format("02-01-2006"))
+	panorama := readSequenceFile(t, filepath.Join(day, "panoramas", "100_0001"))
+	require.Equal(t, "panorama", panorama.Type)
+	require.Equal(t, 3, panorama.Frames)
+	require.Equal(t, "../../photos/DJI_0004.JPG", panorama.Result)
+
+	timelapse := readSequenceFile(t, filepath.Join(day, "hyperlapses", "100_0002"))
+	require.Equal(t, "hyperlapse", timelapse.Type)
+	require.Equal(t, 2, timelapse.Frames)
+	require.Equal(t, "../../videos/DJI_0006.MP4", timelapse.Result)
+
+	indexed := readSequenceFile(t, filepath.Join(day, "panoramas", "100_0003"))
+	require.Equal(t, "../../photos/DJI_0009.JPG", indexed.Result)
+	require.FileExists(t, filepath.Join(day, "panoramas", "100_0003", "pano.html"))
+}
+
+func TestImportSequenceRawMode(t *testing.T) {
+	card := t.TempDir()
+	start := time.Date(2023, 6, 10, 12, 0, 0, 0, time.Local)
+	for i, name := range []string{"DJI_0001.JPG", "DJI_0001.DNG", "DJI_0002.JPG", "DJI_0002.DNG"} {
+		writeCardFile(t, filepath.Join(card, "DCIM", "PANORAMA", "100_0001", name), start.Add(time.Duration(i)*time.Second))
+	}
+
+	for mode, expected := range map[utils.RawMode][]string{
+		utils.RawSeparate: {"DJI_0001.DNG", "DJI_0001.JPG", "DJI_0001.xmp", "DJI_0002.DNG", "DJI_0002.JPG", "DJI_0002.xmp", utils.SequenceFile},
+		utils.RawOnly:     {"DJI_0001.DNG", "DJI_0001.xmp", "DJI_0002.DNG", "DJI_0002.xmp", utils.SequenceFile},
+		utils.JPEGOnly:    {"DJI_0001.JPG", "DJI_0001.xmp", "DJI_0002.JPG", "DJI_0002.xmp", utils.SequenceFile},
+	} {
+		output := t.TempDir()
+		result, err := Entrypoint{}.Import(utils.ImportParams{
+			Input:      card,
+			Output:     output,
+			DateFormat: "dd-mm-yyyy",
+			DateRange:  []time.Time{start.Add(-time.Hour), start.Add(time.Hour)},
+			RawMode:    mode,
+			Sidecars:   true,
+		})
+		require.NoError(t, err)
+		require.Empty(t, result.Errors, mode)
+
+		entries, err := os.ReadDir(filepath.Join(output, start.Format("02-01-2006"), "panoramas", "100_0001"))
+		require.NoError(t, err)
+		names := []string{}
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		require.Equal(t, expected, names, mode)
+	}
+}
@@ -5,11 +5,12 @@ import "regexp"
 type FileType string
 
 const (
-	Video         FileType = "video"
-	Photo         FileType = "photo"
-	Subtitle      FileType = "srt"
-	RawPhoto      FileType = "dng"
-	PanoramaIndex FileType = "panoramaindex"
+	Video              FileType = "video"
+	Photo              FileType = "photo"
+	Subtitle           FileType = "srt"
+	RawPhoto           FileType = "dng"
+	PanoramaIndex      FileType = "panoramaindex"
+	LowResolutionVideo FileType = "lrf"
 )
 
 type FileTypeMatch struct {
@@ -17,8 +18,11 @@ type FileTypeMatch struct {
 	Type  FileType
 }
 
+// DeviceNames maps the model DJI writes to EXIF, MP4 metadata and some SRTs to the product name
 var DeviceNames = map[string]string{
 	"FC1102":  "Spark",
+	"FC2103":  "Mavic Air",
+	"FC2204":  "Mavic 2 Zoom",
 	"FC220":   "Mavic Pro",
 	"FC300C":  "Phantom 3",
 	"FC300S":  "Phantom 3 Pro",
@@ -29,16 +33,19 @@ var DeviceNames = map[string]string{
 	"FC330":   "Phantom 4",
 	"FC3411":  "Air 2S",
 	"FC350":   "X3",
+	"FC3582":  "Mini 3 Pro",
+	"FC3682":  "Mini 3",
 	"FC550":   "X5",
 	"FC6310":  "Phantom 4 Pro",
 	"FC6510":  "X4S",
 	"FC6520":  "X5S",
 	"FC6540":  "X7",
 	"FC7203":  "Mavic Mini",
+	"FC7303":  "Mini 2",
+	"FC8282":  "Air 3",
+	"FC8482":  "Mini 4 Pro",
 	"HG310":   "OSMO",
 	"OT110":   "Osmo Pocket",
 	"L1D-20":  "Mavic 2 Pro",
 	"L2D-20c": "Mavic 3",
-	"FC7303":  "Mini 2",
-	"FC3582":  "Mini 3 Pro",
 }
@@ -17,4 +17,13 @@ var (
 	ErrInvalidSuppliedData      = func(data interface{}) error { return fmt.Errorf("Invalid data: %s", data) }
 	ErrUnsupportedCamera        = func(camera string) error { return fmt.Errorf("camera %s is not supported", camera) }
 	ErrNotFound                 = func(item string) error { return fmt.Errorf("Unable to find %s", item) }
+	ErrDurationMismatch         = func(item string, expected, actual float64) error {
+		return fmt.Errorf("%s: expected a duration of %.2fs, got %.2fs", item, expected, actual)
+	}
+	ErrIncompatibleStreams = func(item, reason string) error { return fmt.Errorf("%s can't be merged: %s", item, reason) }
+	ErrIncompleteClip      = func(item, reason string) error { return fmt.Errorf("clip %s is incomplete: %s", item, reason) }
+	ErrUnsupportedCommand  = func(command, api string) error { return fmt.Errorf("%s is not supported over %s", command, api) }
+	ErrNameConflict        = func(item, renamed string) error {
+		return fmt.Errorf("%s has the name of a file already imported, saved as %s", item, renamed)
+	}
 )
@@ -0,0 +1,137 @@
+package gopro
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+)
+
+type ChapterInfo struct {
+	Key     string
+	Chapter int
+}
+
+var (
+	chapterV2        = regexp.MustCompile(`^(G[XHS])(\d{2})(\d{4})\.(MP4|360)$`)
+	chapterV2Renamed = regexp.MustCompile(`^(G[XHS])(\d{4})-(\d{2})\.(MP4|360)$`)
+	chapterV1First   = regexp.MustCompile(`^GOPR(\d{4})\.MP4$`)
+	chapterV1        = regexp.MustCompile(`^GP(\d{2})(\d{4})\.MP4$`)
+	chapterV1Renamed = regexp.MustCompile(`^GOPR(\d{4})(\d{2})\.MP4$`)
+)
+
+/*
+GetChapterInfo returns the recording a video belongs to and its 1-based chapter number, for original camera names and names given by mmt on import:
+GX011273.MP4 / GX1273-01.MP4 -> GX1273, 1
+GOPR1273.MP4 -> GOPR1273, 1 and GP011273.MP4 / GOPR127301.MP4 -> GOPR1273, 2
+*/
+func GetChapterInfo(filename string) (ChapterInfo, bool) {
+	name := filepath.Base(filename)
+	if m := chapterV2.FindStringSubmatch(name); m != nil {
+		chapter, _ := strconv.Atoi(m[2])
+		return ChapterInfo{Key: m[1] + m[3], Chapter: chapter}, true
+	}
+	if m := chapterV2Renamed.FindStringSubmatch(name); m != nil {
+		chapter, _ := strconv.Atoi(m[3])
+		return ChapterInfo{Key: m[1] + m[2], Chapter: chapter}, true
+	}
+	if m := chapterV1First.FindStringSubmatch(name); m != nil {
+		return ChapterInfo{Key: "GOPR" + m[1], Chapter: 1}, true
+	}
+	if m := chapterV1.FindStringSubmatch(name); m != nil {
+		chapter, _ := strconv.Atoi(m[1])
+		return ChapterInfo{Key: "GOPR" + m[2], Chapter: chapter + 1}, true
+	}
+	if m := chapterV1Renamed.FindStringSubmatch(name); m != nil {
+		chapter, _ := strconv.Atoi(m[2])
+		return ChapterInfo{Key: "GOPR" + m[1], Chapter: chapter + 1}, true
+	}
+	return ChapterInfo{}, false
+}
+
+// GroupChapters groups video paths by recording, each group sorted by chapter. Unrecognized files get a group of their own.
+func GroupChapters(paths []string) (map[string][]string, []string) {
+	groups := map[string][]string{}
+	keys := []string{}
+	for _, path := range paths {
+		info, ok := GetChapterInfo(path)
+		key := info.Key
+		if !ok {
+			key = path
+		}
+		if _, found := groups[key]; !found {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], path)
+	}
+	for _, key := range keys {
+		group := groups[key]
+		sort.SliceStable(group, func(i, j int) bool {
+			a, _ := GetChapterInfo(group[i])
+			b, _ := GetChapterInfo(group[j])
+			return a.Chapter < b.Chapter
+		})
+	}
+	return groups, keys
+}
+
+// FindChapterSets finds the recordings split over several chapters in a folder, or the rest of the recording when given a single chapter
+func FindChapterSets(path string) ([][]string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	folder, key := path, ""
+	if !stat.IsDir() {
+		info, ok := GetChapterInfo(path)
+		if !ok {
+			return nil, mErrors.ErrInvalidSuppliedData(filepath.Base(path))
+		}
+		folder, key = filepath.Dir(path), info.Key
+	}
+
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+	videos := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, ok := GetChapterInfo(entry.Name())
+		if !ok || (key != "" && info.Key != key) {
+			continue
+		}
+		videos = append(videos, filepath.Join(folder, entry.Name()))
+	}
+
+	groups, keys := GroupChapters(videos)
+	sort.Strings(keys)
+	sets := [][]string{}
+	for _, k := range keys {
+		if len(groups[k]) > 1 {
+			sets = append(sets, groups[k])
+		}
+	}
+	if len(sets) == 0 {
+		return nil, mErrors.ErrNotFound("chapters in " + path)
+	}
+	return sets, nil
+}
+
+// MergedChapterName is where a recording gets merged to, eg: GX1273.MP4. GOPR1273.MP4 is also the first chapter of HERO5 and older so those get -merged appended.
+func MergedChapterName(chapters []string) string {
+	info, _ := GetChapterInfo(chapters[0])
+	ext := filepath.Ext(chapters[0])
+	output := filepath.Join(filepath.Dir(chapters[0]), info.Key+ext)
+	for _, chapter := range chapters {
+		if filepath.Base(chapter) == filepath.Base(output) {
+			return filepath.Join(filepath.Dir(chapters[0]), info.Key+"-merged"+ext)
+		}
+	}
+	return output
+}
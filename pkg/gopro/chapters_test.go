@@ -0,0 +1,61 @@
+package gopro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetChapterInfo(t *testing.T) {
+	expected := map[string]ChapterInfo{
+		"GX011273.MP4":   {Key: "GX1273", Chapter: 1},
+		"GH031273.MP4":   {Key: "GH1273", Chapter: 3},
+		"GX1273-02.MP4":  {Key: "GX1273", Chapter: 2},
+		"GS011273.360":   {Key: "GS1273", Chapter: 1},
+		"GOPR1273.MP4":   {Key: "GOPR1273", Chapter: 1},
+		"GP011273.MP4":   {Key: "GOPR1273", Chapter: 2},
+		"GOPR127302.MP4": {Key: "GOPR1273", Chapter: 3},
+	}
+	for name, info := range expected {
+		got, ok := GetChapterInfo(name)
+		require.True(t, ok, name)
+		require.Equal(t, info, got, name)
+	}
+
+	_, ok := GetChapterInfo("G0011273.JPG")
+	require.False(t, ok)
+}
+
+func TestGroupChapters(t *testing.T) {
+	groups, keys := GroupChapters([]string{"a/GX021273.MP4", "a/GX011273.MP4", "a/GX011274.MP4", "a/other.MP4"})
+	require.Equal(t, []string{"GX1273", "GX1274", "a/other.MP4"}, keys)
+	require.Equal(t, []string{"a/GX011273.MP4", "a/GX021273.MP4"}, groups["GX1273"])
+}
+
+func TestFindChapterSets(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"GX1273-02.MP4", "GX1273-01.MP4", "GX1274-01.MP4", "GX011275.MP4", "GX021275.MP4", "GOPR0002.JPG"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte{}, 0o600))
+	}
+
+	sets, err := FindChapterSets(dir)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{filepath.Join(dir, "GX1273-01.MP4"), filepath.Join(dir, "GX1273-02.MP4")},
+		{filepath.Join(dir, "GX011275.MP4"), filepath.Join(dir, "GX021275.MP4")},
+	}, sets)
+
+	sets, err = FindChapterSets(filepath.Join(dir, "GX1273-02.MP4"))
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{filepath.Join(dir, "GX1273-01.MP4"), filepath.Join(dir, "GX1273-02.MP4")}}, sets)
+
+	_, err = FindChapterSets(filepath.Join(dir, "GX1274-01.MP4"))
+	require.Error(t, err)
+}
+
+func TestMergedChapterName(t *testing.T) {
+	require.Equal(t, filepath.Join("a", "GX1273.MP4"), MergedChapterName([]string{"a/GX1273-01.MP4", "a/GX1273-02.MP4"}))
+	require.Equal(t, filepath.Join("a", "GOPR1273-merged.MP4"), MergedChapterName([]string{"a/GOPR1273.MP4", "a/GP011273.MP4"}))
+}
@@ -16,6 +16,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -25,25 +26,29 @@ import (
 	"github.com/vbauerster/mpb/v8"
 )
 
+// sessions with turbo mode possibly enabled, to be reset on ctrl-c
 var (
-	ipAddress = ""
-	gpTurbo   = true
+	sessionsMu     sync.Mutex
+	sessions       = map[*connectSession]bool{}
+	handleKillOnce sync.Once
 )
 
 func handleKill() {
-	c := make(chan os.Signal, 2)
-	ctx := context.Background()
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-c
-		color.Red("\nKilling program, exiting Turbo mode.")
-		if gpTurbo {
-			if err := caller(ctx, ipAddress, "gp/gpTurbo?p=0", nil); err != nil {
-				color.Red("Could not exit turbo mode")
+	handleKillOnce.Do(func() {
+		c := make(chan os.Signal, 2)
+		ctx := context.Background()
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-c
+			color.Red("\nKilling program, exiting Turbo mode.")
+			sessionsMu.Lock()
+			for session := range sessions {
+				session.setTurbo(ctx, false)
 			}
-		}
-		os.Exit(0)
-	}()
+			sessionsMu.Unlock()
+			os.Exit(0)
+		}()
+	})
 }
 
 func caller(ctx context.Context, ip, path string, object interface{}) error {
@@ -57,6 +62,9 @@ func caller(ctx context.Context, ip, path string, object interface{}) error {
 		return err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s: %s", path, resp.Status)
+	}
 	if object != nil {
 		err = json.NewDecoder(resp.Body).Decode(object)
 		if err != nil {
@@ -103,8 +111,7 @@ func GetGoProNetworkAddresses(ctx context.Context) ([]ConnectDevice, error) {
 			ipv4Addr := a.(*net.IPNet).IP.To4()
 			if r.MatchString(ipv4Addr.String()) {
 				correctIP := ipv4Addr.String()[:len(ipv4Addr.String())-1] + "1"
-				gpInfo := &cameraInfo{}
-				err := caller(ctx, correctIP, "gp/gpControl/info", gpInfo)
+				gpInfo, err := newConnectClient(ctx, correctIP).Info(ctx)
 				if err != nil {
 					continue
 				}
@@ -120,12 +127,7 @@ func GetGoProNetworkAddresses(ctx context.Context) ([]ConnectDevice, error) {
 
 func GetMediaList(in string) (*MediaList, error) {
 	ctx := context.Background()
-	gpMediaList := &MediaList{}
-	err := caller(ctx, in, "gp/gpMediaList", gpMediaList)
-	if err != nil {
-		return nil, err
-	}
-	return gpMediaList, nil
+	return newConnectClient(ctx, in).MediaList(ctx)
 }
 
 func forceGetFolder(path string) {
@@ -137,77 +139,234 @@ func forceGetFolder(path string) {
 	}
 }
 
-func validateIP() bool {
+var chaptered = regexp.MustCompile(`GP\d+.MP4`)
+
+// connectVideoName is the name a video downloaded over Connect is saved as
+func connectVideoName(x string, verType Type) string {
+	if verType == V2 {
+		return fmt.Sprintf("%s%s-%s.%s", x[:2], x[4:][:4], x[2:][:2], "MP4")
+	}
+	if verType == V1 && chaptered.MatchString(x) {
+		return fmt.Sprintf("GOPR%s%s.%s", x[4:][:4], x[2:][:2], "MP4")
+	}
+	return x
+}
+
+func validateIP(ip string) bool {
 	valid := regexp.MustCompile(`^((25[0-5]|(2[0-4]|1\d|[1-9]|)\d)\.?\b){4}$`)
-	return valid.MatchString(ipAddress)
+	return valid.MatchString(ip)
 }
 
-func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
-	var verType Type
-	var result utils.Result
-	ipAddress = params.Input
+// connectSession is a single camera being imported from, several can run at once
+type connectSession struct {
+	ip      string
+	client  connectClient
+	info    *cameraInfo
+	verType Type
+	turbo   bool
 
-	// handle ctrl-c
-	handleKill()
+	group int64
+	bars  int64
+}
 
-	if !validateIP() {
-		return nil, mErrors.ErrInvalidSuppliedData(ipAddress)
+func newConnectSession(ctx context.Context, ip string) (*connectSession, error) {
+	if !validateIP(ip) {
+		return nil, mErrors.ErrInvalidSuppliedData(ip)
 	}
-	gpInfo := &cameraInfo{}
-	ctx := context.Background()
-	err := caller(ctx, params.Input, "gp/gpControl/info", gpInfo)
+	client := newConnectClient(ctx, ip)
+	gpInfo, err := client.Info(ctx)
 	if err != nil {
-		return nil, mErrors.ErrNotFound("Connect camera: " + params.Input)
+		return nil, mErrors.ErrNotFound("Connect camera: " + ip)
 	}
-	cameraName := gpInfo.Info.ModelName
+	s := &connectSession{ip: ip, client: client, info: gpInfo}
 
 	root := strings.Split(gpInfo.Info.FirmwareVersion, ".")[0]
 
 	switch root {
 	case "HD9", "H21", "H22", "H23":
-		verType = V2
-		gpTurbo = true
+		s.verType = V2
+		s.turbo = true
 	case "HD6", "HD7", "HD8":
-		verType = V2
-		gpTurbo = false
+		s.verType = V2
+		s.turbo = false
 	default:
-		verType = V1
-		gpTurbo = false
+		s.verType = V1
+		s.turbo = false
+	}
+	// every Open GoPro camera supports turbo transfer
+	if client.API() == openGoProAPI {
+		s.verType = V2
+		s.turbo = true
+	}
+	return s, nil
+}
+
+func (s *connectSession) id() string {
+	if serial := s.info.Info.SerialNumber; serial != "" {
+		return serial
+	}
+	return s.ip
+}
+
+func (s *connectSession) setTurbo(ctx context.Context, enable bool) {
+	if !s.turbo {
+		return
+	}
+	if err := s.client.Turbo(ctx, enable); err != nil {
+		if enable {
+			color.Red("Error activating Turbo on %s! Download speeds will be much slower", s.ip)
+		} else {
+			color.Red("Could not exit turbo mode on %s", s.ip)
+		}
+	}
+}
+
+func (s *connectSession) start(ctx context.Context) {
+	sessionsMu.Lock()
+	sessions[s] = true
+	sessionsMu.Unlock()
+	s.setTurbo(ctx, true)
+}
+
+func (s *connectSession) stop(ctx context.Context) {
+	s.setTurbo(ctx, false)
+	sessionsMu.Lock()
+	delete(sessions, s)
+	sessionsMu.Unlock()
+}
+
+// nextBarPriority keeps the bars of each camera together, in the order they were added
+func (s *connectSession) nextBarPriority() mpb.BarOption {
+	return mpb.BarPriority(int(s.group<<20 + atomic.AddInt64(&s.bars, 1)))
+}
+
+func newConnectProgress() *mpb.Progress {
+	return mpb.New(
+		mpb.WithWidth(60),
+		mpb.WithRefreshRate(180*time.Millisecond))
+}
+
+func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
+	// handle ctrl-c
+	handleKill()
+
+	ctx := context.Background()
+	s, err := newConnectSession(ctx, params.Input)
+	if err != nil {
+		return nil, err
 	}
-	// activate turbo
 
-	if gpTurbo {
-		err = caller(ctx, params.Input, "gp/gpTurbo?p=1", nil)
+	progress := newConnectProgress()
+	s.start(ctx)
+	result, err := s.importMedia(ctx, params, progress, s.info.Info.ModelName, "")
+	progress.Shutdown()
+	s.stop(ctx)
+	return result, err
+}
+
+type ConnectResult struct {
+	Device ConnectDevice
+	Result *utils.Result
+	Err    error
+}
+
+// ImportConnectAll imports from every camera at once, each with its own group of progress bars
+func ImportConnectAll(params utils.ImportParams, ips []string) []ConnectResult {
+	handleKill()
+
+	ctx := context.Background()
+	results := make([]ConnectResult, len(ips))
+	connected := []*connectSession{}
+	models := map[string]int{}
+	for i, ip := range ips {
+		results[i].Device.IP = ip
+		s, err := newConnectSession(ctx, ip)
 		if err != nil {
-			color.Red("Error activating Turbo! Download speeds will be much slower")
+			results[i].Err = err
+			connected = append(connected, nil)
+			continue
+		}
+		s.group = int64(i)
+		results[i].Device.Info = *s.info
+		models[s.info.Info.ModelName]++
+		connected = append(connected, s)
+	}
+
+	progress := newConnectProgress()
+	var wg sync.WaitGroup
+	for i, s := range connected {
+		if s == nil {
+			continue
+		}
+		// identical models would otherwise end up in the same folder
+		cameraName := s.info.Info.ModelName
+		if models[cameraName] > 1 {
+			cameraName = fmt.Sprintf("%s %s", cameraName, s.id())
 		}
+		label := fmt.Sprintf("📹 %s (%s)", cameraName, s.ip)
+
+		wg.Add(1)
+		go func(i int, s *connectSession, cameraName, label string) {
+			defer wg.Done()
+			s.start(ctx)
+			defer s.stop(ctx)
+			results[i].Result, results[i].Err = s.importMedia(ctx, params, progress, cameraName, label)
+		}(i, s, cameraName, label)
 	}
+	wg.Wait()
+	progress.Shutdown()
+	return results
+}
+
+// importMedia downloads and sorts every matching file, label adds a header bar counting files done
+func (s *connectSession) importMedia(ctx context.Context, params utils.ImportParams, progress *mpb.Progress, cameraName, label string) (*utils.Result, error) {
+	var result utils.Result
+	verType := s.verType
 
-	gpMediaList, err := GetMediaList(params.Input)
+	gpMediaList, err := s.client.MediaList(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	var wg sync.WaitGroup
-	progressBar := mpb.New(mpb.WithWaitGroup(&wg),
-		mpb.WithWidth(60),
-		mpb.WithRefreshRate(180*time.Millisecond))
+	var header *mpb.Bar
+	queued := int64(0)
+	if label != "" {
+		header = utils.GetNewBar(progress, 0, label, utils.Percentage, s.nextBarPriority())
+	}
+	add := func() {
+		queued++
+		wg.Add(1)
+	}
+	done := func() {
+		if header != nil {
+			header.Increment()
+		}
+		wg.Done()
+	}
 
 	inlineCounter := utils.ResultCounter{}
+	sequences := sequenceFolders{}
+	chapters := chapterSets{}
 
 	unsorted := filepath.Join(params.Output, "unsorted")
+	if label != "" {
+		// cameras share file names, keep their downloads apart
+		unsorted = filepath.Join(params.Output, "unsorted-"+s.id())
+	}
 	if _, err := os.Stat(unsorted); os.IsNotExist(err) {
 		_ = os.Mkdir(unsorted, 0o755)
 	}
 
-	chaptered := regexp.MustCompile(`GP\d+.MP4`)
-
 	for _, folder := range gpMediaList.Media {
 		for _, goprofile := range folder.Fs {
 			for _, fileTypeMatch := range FileTypeMatches[verType] {
 				if !fileTypeMatch.Regex.MatchString(goprofile.N) {
 					continue
 				}
+				if fileTypeMatch.Type == Video || fileTypeMatch.Type == ChapteredVideo {
+					chapters.see(goprofile.N)
+				}
 				i, err := strconv.ParseInt(goprofile.Mod, 10, 64)
 				if err != nil {
 					continue
@@ -228,27 +387,19 @@ func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
 					continue
 				}
 
-				wg.Add(1)
-				bar := utils.GetNewBar(progressBar, goprofile.S, goprofile.N, utils.IoTX)
+				add()
+				bar := utils.GetNewBar(progress, goprofile.S, goprofile.N, utils.IoTX, s.nextBarPriority())
 
 				switch fileTypeMatch.Type {
 				case Video, ChapteredVideo:
 
 					go func(in, folder, origFilename, unsorted string, origSize int64, lrvSize int, bar *mpb.Bar) {
-						defer wg.Done()
-						x := origFilename
-						filename := origFilename
-
-						if verType == V2 {
-							filename = fmt.Sprintf("%s%s-%s.%s", x[:2], x[4:][:4], x[2:][:2], "MP4")
-						}
-						if verType == V1 && chaptered.MatchString(x) {
-							filename = fmt.Sprintf("GOPR%s%s.%s", x[4:][:4], x[2:][:2], "MP4")
-						}
+						defer done()
+						filename := connectVideoName(origFilename, verType)
 
 						err := utils.DownloadFile(
 							filepath.Join(unsorted, origFilename),
-							fmt.Sprintf("http://%s:8080/videos/DCIM/%s/%s", in, folder, origFilename),
+							s.client.MediaURL(folder, origFilename),
 							bar)
 						if err != nil {
 							bar.EwmaSetCurrent(origSize, 1*time.Millisecond)
@@ -262,8 +413,7 @@ func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
 						// Move to actual folder
 
 						finalPath := utils.GetOrder(params.Sort, locationService, filepath.Join(unsorted, origFilename), params.Output, mediaDate, cameraName)
-						gpFileInfo := &goProMediaMetadata{}
-						err = caller(ctx, in, fmt.Sprintf("gp/gpMediaMetadata?p=%s/%s&t=v4info", folder, origFilename), gpFileInfo)
+						gpFileInfo, err := s.client.MediaMetadata(ctx, folder, origFilename)
 						if err != nil {
 							inlineCounter.SetFailure(err, origFilename)
 							return
@@ -292,6 +442,20 @@ func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
 							inlineCounter.SetFailure(err, origFilename)
 							return
 						}
+						chapters.add(filepath.Join(finalPath, "videos", importanceName, rfpsFolder, filename))
+						if params.Sidecars {
+							sidecar := utils.XMP{
+								Model:        cameraName,
+								SerialNumber: s.info.Info.SerialNumber,
+								CreateDate:   tm,
+								Rating:       utils.HiLightRating(len(gpFileInfo.Hi)),
+							}
+							if importanceName != "" {
+								sidecar.Keywords = []string{importanceName}
+							}
+							chapters.addSidecar(filepath.Join(finalPath, "videos", importanceName, rfpsFolder, filename), sidecar)
+							writeSidecar(filepath.Join(finalPath, "videos", importanceName, rfpsFolder, filename), sidecar, &inlineCounter)
+						}
 
 						// download proxy
 						if lrvSize > 0 && !params.SkipAuxiliaryFiles {
@@ -300,10 +464,10 @@ func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
 								proxyVideoName = strings.Replace(origFilename, ".MP4", ".LRV", -1)
 							}
 
-							proxyVideoBar := utils.GetNewBar(progressBar, int64(lrvSize), proxyVideoName, utils.IoTX)
+							proxyVideoBar := utils.GetNewBar(progress, int64(lrvSize), proxyVideoName, utils.IoTX, s.nextBarPriority())
 							err := utils.DownloadFile(
 								filepath.Join(unsorted, proxyVideoName),
-								fmt.Sprintf("http://%s:8080/videos/DCIM/%s/%s", in, folder, proxyVideoName),
+								s.client.MediaURL(folder, proxyVideoName),
 								proxyVideoBar)
 							if err != nil {
 								proxyVideoBar.EwmaSetCurrent(int64(lrvSize), 1*time.Millisecond)
@@ -342,33 +506,44 @@ func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
 						},
 					}
 
-					hasRawPhoto := goprofile.Raw == "1"
+					hasRawPhoto := goprofile.Raw == "1" && params.RawMode.Keep(true, true)
 					if hasRawPhoto {
-						wg.Add(1)
 						rawPhotoName := strings.Replace(goprofile.N, ".JPG", ".GPR", -1)
 
-						rawPhotoTotal, err := head(fmt.Sprintf("http://%s:8080/videos/DCIM/%s/%s", params.Input, folder.D, rawPhotoName))
+						rawPhotoTotal, err := head(s.client.MediaURL(folder.D, rawPhotoName))
 						if err != nil {
-							continue
+							inlineCounter.SetFailure(err, rawPhotoName)
+						} else if !params.RawMode.Keep(false, true) {
+							// only the raw is kept, it takes the place of the JPEG
+							bar.SetTotal(int64(rawPhotoTotal), false)
+							totalPhotos[0] = photo{
+								Name:   rawPhotoName,
+								Folder: folder.D,
+								IsRaw:  true,
+								Bar:    bar,
+								Size:   rawPhotoTotal,
+							}
+						} else {
+							add()
+							rawPhotoBar := utils.GetNewBar(progress, int64(rawPhotoTotal), rawPhotoName, utils.IoTX, s.nextBarPriority())
+							totalPhotos = append(totalPhotos, photo{
+								Name:   rawPhotoName,
+								Folder: folder.D,
+								IsRaw:  true,
+								Bar:    rawPhotoBar,
+								Size:   rawPhotoTotal,
+							})
 						}
-
-						rawPhotoBar := utils.GetNewBar(progressBar, int64(rawPhotoTotal), rawPhotoName, utils.IoTX)
-						totalPhotos = append(totalPhotos, photo{
-							Name:   rawPhotoName,
-							Folder: folder.D,
-							IsRaw:  true,
-							Bar:    rawPhotoBar,
-							Size:   rawPhotoTotal,
-						})
 					}
 
+					paired := len(totalPhotos) > 1
 					for _, item := range totalPhotos {
 						go func(in string, nowPhoto photo, unsorted string) {
-							defer wg.Done()
+							defer done()
 
 							err := utils.DownloadFile(
 								filepath.Join(unsorted, nowPhoto.Name),
-								fmt.Sprintf("http://%s:8080/videos/DCIM/%s/%s", in, nowPhoto.Folder, nowPhoto.Name),
+								s.client.MediaURL(nowPhoto.Folder, nowPhoto.Name),
 								nowPhoto.Bar,
 							)
 							if err != nil {
@@ -383,7 +558,7 @@ func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
 
 								photoPath := filepath.Join(finalPath, "photos")
 								if nowPhoto.IsRaw {
-									photoPath = filepath.Join(photoPath, "raw")
+									photoPath = params.RawMode.RawFolder(photoPath)
 								}
 								forceGetFolder(photoPath)
 
@@ -395,32 +570,43 @@ func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
 									inlineCounter.SetFailure(err, nowPhoto.Name)
 									return
 								}
+								if nowPhoto.IsRaw || params.Sidecars && utils.WritesSidecar(false, params.RawMode.Alongside(paired)) {
+									sidecar := utils.XMP{Model: cameraName, SerialNumber: s.info.Info.SerialNumber, CreateDate: tm}
+									if gpFileInfo, err := s.client.MediaMetadata(ctx, nowPhoto.Folder, strings.Replace(nowPhoto.Name, ".GPR", ".JPG", -1)); err == nil {
+										if count, err := strconv.Atoi(gpFileInfo.Hc); err == nil {
+											sidecar.Rating = utils.HiLightRating(count)
+										}
+									}
+									writeSidecar(filepath.Join(photoPath, nowPhoto.Name), sidecar, &inlineCounter)
+								}
 							}
 						}(params.Input, item, unsorted)
 					}
 
 				case Multishot:
-					filebaseroot := goprofile.N[:4]
+					filebaseroot := sequenceGroup(goprofile.N)
+					kind := sequenceTypes[goprofile.T]
 
 					for i := goprofile.B; i <= goprofile.L; i++ {
 						if i > goprofile.B {
-							wg.Add(1)
+							add()
 						}
 						filename := fmt.Sprintf("%s%04d.JPG", filebaseroot, i)
 
-						gpFileInfo := &goProMediaMetadata{}
-						err = caller(ctx, params.Input, fmt.Sprintf("gp/gpMediaMetadata?p=%s/%s&t=v4info", folder.D, filename), gpFileInfo)
+						gpFileInfo, err := s.client.MediaMetadata(ctx, folder.D, filename)
 						if err != nil {
-							log.Fatal(err.Error())
+							inlineCounter.SetFailure(err, filename)
+							done()
+							continue
 						}
-						multiShotBar := utils.GetNewBar(progressBar, gpFileInfo.S, filename, utils.IoTX)
+						multiShotBar := utils.GetNewBar(progress, gpFileInfo.S, filename, utils.IoTX, s.nextBarPriority())
 
-						go func(in, folder, origFilename, unsorted string, origSize int64) {
-							defer wg.Done()
+						go func(in, folder, origFilename, unsorted string, origSize int64, created string) {
+							defer done()
 
 							err := utils.DownloadFile(
 								filepath.Join(unsorted, origFilename),
-								fmt.Sprintf("http://%s:8080/videos/DCIM/%s/%s", in, folder, origFilename),
+								s.client.MediaURL(folder, origFilename),
 								multiShotBar,
 							)
 							if err != nil {
@@ -431,18 +617,24 @@ func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
 								inlineCounter.SetSuccess()
 								// Move to actual folder
 								finalPath := utils.GetOrder(params.Sort, locationService, filepath.Join(unsorted, origFilename), params.Output, mediaDate, cameraName)
-								forceGetFolder(filepath.Join(finalPath, "multishot", filebaseroot))
+								sequenceFolder := filepath.Join(finalPath, "multishot", filebaseroot)
+								forceGetFolder(sequenceFolder)
 
 								err := os.Rename(
 									filepath.Join(unsorted, origFilename),
-									filepath.Join(finalPath, "multishot", filebaseroot, origFilename),
+									filepath.Join(sequenceFolder, origFilename),
 								)
 								if err != nil {
 									inlineCounter.SetFailure(err, origFilename)
 									return
 								}
+								// keep the capture time, sequence.json is built from it
+								if cre, err := strconv.ParseInt(created, 10, 64); err == nil {
+									_ = os.Chtimes(filepath.Join(sequenceFolder, origFilename), time.Unix(cre, 0), time.Unix(cre, 0))
+								}
+								sequences.add(sequenceFolder, kind)
 							}
-						}(params.Input, folder.D, filename, unsorted, gpFileInfo.S)
+						}(params.Input, folder.D, filename, unsorted, gpFileInfo.S, gpFileInfo.Cre)
 					}
 
 				default:
@@ -454,12 +646,14 @@ func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
 		}
 	}
 
+	if header != nil {
+		header.SetTotal(queued, false)
+	}
 	wg.Wait()
-	progressBar.Shutdown()
-	if gpTurbo {
-		if err := caller(ctx, params.Input, "gp/gpTurbo?p=0", nil); err != nil {
-			color.Red("Could not exit turbo mode")
-		}
+	sequences.finish(params.Sequences, progress, &inlineCounter)
+	chapters.merge(params.Chapters, progress, &inlineCounter)
+	if header != nil {
+		header.SetTotal(-1, true)
 	}
 	result.Errors = append(result.Errors, inlineCounter.Get().Errors...)
 	result.FilesImported += inlineCounter.Get().FilesImported
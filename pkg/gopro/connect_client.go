@@ -0,0 +1,199 @@
+package gopro
+
+/* Clients for the two HTTP APIs exposed over GoPro Connect */
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type connectClient interface {
+	API() string
+	Info(ctx context.Context) (*cameraInfo, error)
+	MediaList(ctx context.Context) (*MediaList, error)
+	MediaMetadata(ctx context.Context, folder, file string) (*goProMediaMetadata, error)
+	Turbo(ctx context.Context, enable bool) error
+	HiLight(ctx context.Context, folder, file string, ms int) error
+	MediaURL(folder, file string) string
+	Delete(ctx context.Context, folder, file string) error
+	DeleteAll(ctx context.Context) error
+
+	// remote control, see control.go
+	State(ctx context.Context) (*cameraState, error)
+	Shutter(ctx context.Context, start bool) error
+	Preset(ctx context.Context, preset Preset) error
+	Setting(ctx context.Context, setting, option int) error
+	DateTime(ctx context.Context, t time.Time) error
+	Sleep(ctx context.Context) error
+}
+
+const (
+	legacyAPI    = "gpControl"
+	openGoProAPI = "Open GoPro"
+)
+
+func mediaURL(host, folder, file string) string {
+	return fmt.Sprintf("http://%s/videos/DCIM/%s/%s", host, folder, file)
+}
+
+func onOff(enable bool) int {
+	if enable {
+		return 1
+	}
+	return 0
+}
+
+// legacyClient uses the gp/gpControl API, HERO4 - HERO8 and every camera before the Open GoPro firmware
+type legacyClient struct {
+	host, mediaHost string
+}
+
+func (legacyClient) API() string { return legacyAPI }
+
+func (c legacyClient) Info(ctx context.Context) (*cameraInfo, error) {
+	gpInfo := &cameraInfo{}
+	err := caller(ctx, c.host, "gp/gpControl/info", gpInfo)
+	if err != nil {
+		return nil, err
+	}
+	return gpInfo, nil
+}
+
+func (c legacyClient) MediaList(ctx context.Context) (*MediaList, error) {
+	gpMediaList := &MediaList{}
+	err := caller(ctx, c.host, "gp/gpMediaList", gpMediaList)
+	if err != nil {
+		return nil, err
+	}
+	return gpMediaList, nil
+}
+
+func (c legacyClient) MediaMetadata(ctx context.Context, folder, file string) (*goProMediaMetadata, error) {
+	gpFileInfo := &goProMediaMetadata{}
+	err := caller(ctx, c.host, fmt.Sprintf("gp/gpMediaMetadata?p=%s/%s&t=v4info", folder, file), gpFileInfo)
+	if err != nil {
+		return nil, err
+	}
+	return gpFileInfo, nil
+}
+
+func (c legacyClient) Turbo(ctx context.Context, enable bool) error {
+	return caller(ctx, c.host, fmt.Sprintf("gp/gpTurbo?p=%d", onOff(enable)), nil)
+}
+
+func (c legacyClient) HiLight(ctx context.Context, folder, file string, ms int) error {
+	return caller(ctx, c.host, fmt.Sprintf("gp/gpControl/command/storage/tag_moment/playback?p=%s/%s&tag=%d", folder, file, ms), nil)
+}
+
+func (c legacyClient) MediaURL(folder, file string) string {
+	return mediaURL(c.mediaHost, folder, file)
+}
+
+func (c legacyClient) Delete(ctx context.Context, folder, file string) error {
+	return caller(ctx, c.host, fmt.Sprintf("gp/gpControl/command/storage/delete?p=%s/%s", folder, file), nil)
+}
+
+func (c legacyClient) DeleteAll(ctx context.Context) error {
+	return caller(ctx, c.host, "gp/gpControl/command/storage/delete/all", nil)
+}
+
+// openGoProClient uses the Open GoPro HTTP API, HERO9 Black onwards with up to date firmware
+type openGoProClient struct {
+	host string
+}
+
+type openGoProInfo struct {
+	ModelNumber     int    `json:"model_number"`
+	ModelName       string `json:"model_name"`
+	FirmwareVersion string `json:"firmware_version"`
+	SerialNumber    string `json:"serial_number"`
+	ApMacAddr       string `json:"ap_mac_addr"`
+	ApSsid          string `json:"ap_ssid"`
+}
+
+func (openGoProClient) API() string { return openGoProAPI }
+
+func (c openGoProClient) Info(ctx context.Context) (*cameraInfo, error) {
+	ogpInfo := &openGoProInfo{}
+	err := caller(ctx, c.host, "gopro/camera/info", ogpInfo)
+	if err != nil {
+		return nil, err
+	}
+	gpInfo := &cameraInfo{}
+	gpInfo.Info.ModelNumber = ogpInfo.ModelNumber
+	gpInfo.Info.ModelName = ogpInfo.ModelName
+	gpInfo.Info.FirmwareVersion = ogpInfo.FirmwareVersion
+	gpInfo.Info.SerialNumber = ogpInfo.SerialNumber
+	gpInfo.Info.ApMac = ogpInfo.ApMacAddr
+	gpInfo.Info.ApSsid = ogpInfo.ApSsid
+	return gpInfo, nil
+}
+
+func (c openGoProClient) MediaList(ctx context.Context) (*MediaList, error) {
+	gpMediaList := &MediaList{}
+	err := caller(ctx, c.host, "gopro/media/list", gpMediaList)
+	if err != nil {
+		return nil, err
+	}
+	return gpMediaList, nil
+}
+
+func (c openGoProClient) MediaMetadata(ctx context.Context, folder, file string) (*goProMediaMetadata, error) {
+	gpFileInfo := &goProMediaMetadata{}
+	err := caller(ctx, c.host, "gopro/media/info?path="+url.QueryEscape(folder+"/"+file), gpFileInfo)
+	if err != nil {
+		return nil, err
+	}
+	return gpFileInfo, nil
+}
+
+func (c openGoProClient) Turbo(ctx context.Context, enable bool) error {
+	return caller(ctx, c.host, fmt.Sprintf("gopro/media/turbo_transfer?p=%d", onOff(enable)), nil)
+}
+
+func (c openGoProClient) HiLight(ctx context.Context, folder, file string, ms int) error {
+	return caller(ctx, c.host, fmt.Sprintf("gopro/media/hilight/file?path=%s&ms=%d", url.QueryEscape(folder+"/"+file), ms), nil)
+}
+
+func (c openGoProClient) MediaURL(folder, file string) string {
+	return mediaURL(c.host, folder, file)
+}
+
+func (c openGoProClient) Delete(ctx context.Context, folder, file string) error {
+	return caller(ctx, c.host, "gopro/media/delete/file?path="+url.QueryEscape(folder+"/"+file), nil)
+}
+
+// DeleteAll deletes the files one by one, Open GoPro has no endpoint to wipe the card. Multishot groups go in one call
+func (c openGoProClient) DeleteAll(ctx context.Context) error {
+	gpMediaList, err := c.MediaList(ctx)
+	if err != nil {
+		return err
+	}
+	for _, folder := range gpMediaList.Media {
+		for _, goprofile := range folder.Fs {
+			endpoint := "gopro/media/delete/file"
+			if goprofile.B != 0 || goprofile.L != 0 {
+				endpoint = "gopro/media/delete/group"
+			}
+			if err := caller(ctx, c.host, endpoint+"?path="+url.QueryEscape(folder.D+"/"+goprofile.N), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// probeConnectClient prefers Open GoPro when the camera answers its state endpoint
+func probeConnectClient(ctx context.Context, openGoProHost, legacyHost, mediaHost string) connectClient {
+	state := map[string]interface{}{}
+	if err := caller(ctx, openGoProHost, "gopro/camera/state", &state); err == nil {
+		return openGoProClient{host: openGoProHost}
+	}
+	return legacyClient{host: legacyHost, mediaHost: mediaHost}
+}
+
+func newConnectClient(ctx context.Context, ip string) connectClient {
+	return probeConnectClient(ctx, ip+":8080", ip, ip+":8080")
+}
@@ -0,0 +1,64 @@
+package gopro
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func probeFakeCamera(camera *fakeCamera) connectClient {
+	return probeConnectClient(context.Background(), camera.host(), camera.host(), camera.host())
+}
+
+func TestProbeConnectClient(t *testing.T) {
+	require.Equal(t, openGoProAPI, probeFakeCamera(newFakeCamera(t, true)).API())
+	require.Equal(t, legacyAPI, probeFakeCamera(newFakeCamera(t, false)).API())
+}
+
+func TestConnectClients(t *testing.T) {
+	for _, openGoPro := range []bool{true, false} {
+		camera := newFakeCamera(t, openGoPro)
+		client := probeFakeCamera(camera)
+		ctx := context.Background()
+
+		info, err := client.Info(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, info.Info.ModelName)
+		require.NotEmpty(t, info.Info.FirmwareVersion)
+
+		mediaList, err := client.MediaList(ctx)
+		require.NoError(t, err)
+		require.Len(t, mediaList.Media, 1)
+		require.Equal(t, fakeFolder, mediaList.Media[0].D)
+		require.Len(t, mediaList.Media[0].Fs, 3)
+
+		require.NoError(t, client.Turbo(ctx, true))
+		require.True(t, camera.turbo)
+		require.NoError(t, client.Turbo(ctx, false))
+		require.False(t, camera.turbo)
+
+		require.NoError(t, client.HiLight(ctx, fakeFolder, "GX010001.MP4", 2500))
+		metadata, err := client.MediaMetadata(ctx, fakeFolder, "GX010001.MP4")
+		require.NoError(t, err)
+		require.Equal(t, []int{2500}, metadata.Hi)
+		require.Equal(t, 10, metadata.Dur)
+		require.Equal(t, int64(5), metadata.S)
+
+		_, err = client.MediaMetadata(ctx, fakeFolder, "GX019999.MP4")
+		require.Error(t, err)
+
+		resp, err := http.Get(client.MediaURL(fakeFolder, "GX010001.MP4"))
+		require.NoError(t, err)
+		content, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, "video", string(content))
+
+		size, err := head(client.MediaURL(fakeFolder, "GOPR0002.JPG"))
+		require.NoError(t, err)
+		require.Equal(t, 5, size)
+	}
+}
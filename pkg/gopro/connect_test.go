@@ -0,0 +1,290 @@
+package gopro
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/stretchr/testify/require"
+	"github.com/vbauerster/mpb/v8"
+)
+
+func TestImportMediaFromSeveralCameras(t *testing.T) {
+	output := t.TempDir()
+	params := utils.ImportParams{
+		Output:             output,
+		SkipAuxiliaryFiles: true,
+		DateFormat:         "dd-mm-yyyy",
+		DateRange:          []time.Time{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Now()},
+		Sort:               utils.SortOptions{ByCamera: true},
+	}
+
+	ctx := context.Background()
+	progress := mpb.New(mpb.WithOutput(io.Discard))
+	cameras := []*fakeCamera{newFakeCamera(t, true), newFakeCamera(t, true)}
+	results := make([]*utils.Result, len(cameras))
+	errs := make([]error, len(cameras))
+
+	var wg sync.WaitGroup
+	for i, camera := range cameras {
+		client := probeFakeCamera(camera)
+		info, err := client.Info(ctx)
+		require.NoError(t, err)
+		info.Info.SerialNumber += string(rune('A' + i))
+		s := &connectSession{ip: camera.host(), client: client, info: info, verType: V2, turbo: true, group: int64(i)}
+
+		wg.Add(1)
+		go func(i int, s *connectSession) {
+			defer wg.Done()
+			s.start(ctx)
+			defer s.stop(ctx)
+			results[i], errs[i] = s.importMedia(ctx, params, progress, s.id(), s.id())
+		}(i, s)
+	}
+	wg.Wait()
+	progress.Shutdown()
+
+	for i, camera := range cameras {
+		require.NoError(t, errs[i])
+		require.Equal(t, 2, results[i].FilesImported)
+		require.Empty(t, results[i].Errors)
+		require.False(t, camera.turbo)
+		require.Contains(t, camera.requests, "/gopro/media/turbo_transfer?p=1")
+	}
+	require.Empty(t, sessions)
+
+	imported := map[string]int{}
+	err := filepath.Walk(output, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			imported[info.Name()]++
+		}
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"GX0001-01.MP4": 2, "GOPR0002.JPG": 2}, imported)
+}
+
+func TestImportSequence(t *testing.T) {
+	camera := newFakeCamera(t, false)
+	camera.addSequence("G0030001.JPG", "t", 1, 4)
+
+	output := t.TempDir()
+	params := utils.ImportParams{
+		Output:             output,
+		SkipAuxiliaryFiles: true,
+		DateFormat:         "dd-mm-yyyy",
+		DateRange:          []time.Time{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Now()},
+	}
+
+	ctx := context.Background()
+	client := probeFakeCamera(camera)
+	info, err := client.Info(ctx)
+	require.NoError(t, err)
+	s := &connectSession{ip: camera.host(), client: client, info: info, verType: V2}
+
+	progress := mpb.New(mpb.WithOutput(io.Discard))
+	result, err := s.importMedia(ctx, params, progress, "HERO7 Black", "")
+	progress.Shutdown()
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	require.Equal(t, 6, result.FilesImported)
+
+	matches, err := filepath.Glob(filepath.Join(output, "*", "multishot", "G003", utils.SequenceFile))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+
+	content, err := os.ReadFile(matches[0])
+	require.NoError(t, err)
+	sequence := utils.Sequence{}
+	require.NoError(t, json.Unmarshal(content, &sequence))
+	require.Equal(t, "G003", sequence.Name)
+	require.Equal(t, "timelapse", sequence.Type)
+	require.Equal(t, 4, sequence.Frames)
+	require.Equal(t, 1.0, sequence.Interval)
+	require.Equal(t, "G0030001.JPG", sequence.FirstFile)
+	require.Equal(t, "G0030004.JPG", sequence.LastFile)
+	require.Equal(t, int64(1672490791), sequence.First.Unix())
+}
+
+func TestImportSequenceMissingFrame(t *testing.T) {
+	camera := newFakeCamera(t, false)
+	camera.addSequence("G0030001.JPG", "t", 1, 4)
+	delete(camera.files, "G0030003.JPG")
+
+	params := utils.ImportParams{
+		Output:             t.TempDir(),
+		SkipAuxiliaryFiles: true,
+		DateFormat:         "dd-mm-yyyy",
+		DateRange:          []time.Time{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Now()},
+	}
+
+	ctx := context.Background()
+	client := probeFakeCamera(camera)
+	info, err := client.Info(ctx)
+	require.NoError(t, err)
+	s := &connectSession{ip: camera.host(), client: client, info: info, verType: V2}
+
+	// the frame fails on its own, the rest of the import goes on
+	progress := mpb.New(mpb.WithOutput(io.Discard))
+	result, err := s.importMedia(ctx, params, progress, "HERO7 Black", "")
+	progress.Shutdown()
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	require.Equal(t, []string{"G0030003.JPG"}, result.FilesNotImported)
+	require.Equal(t, 5, result.FilesImported)
+}
+
+func TestImportRawPhotos(t *testing.T) {
+	// side by side, the JPEG and its raw share the sidecar of the raw
+	for mode, expected := range map[utils.RawMode][]string{
+		utils.RawSeparate: {"GOPR0002.JPG", "GOPR0002.xmp", "raw/GOPR0002.GPR", "raw/GOPR0002.xmp"},
+		utils.RawPair:     {"GOPR0002.GPR", "GOPR0002.JPG", "GOPR0002.xmp"},
+		utils.RawOnly:     {"GOPR0002.GPR", "GOPR0002.xmp"},
+		utils.JPEGOnly:    {"GOPR0002.JPG", "GOPR0002.xmp"},
+	} {
+		camera := newFakeCamera(t, true)
+		camera.files["GOPR0002.GPR"] = []byte("raw")
+		camera.hilights["GOPR0002.JPG"] = []int{1000, 2000}
+
+		output := t.TempDir()
+		params := utils.ImportParams{
+			Output:             output,
+			SkipAuxiliaryFiles: true,
+			DateFormat:         "dd-mm-yyyy",
+			DateRange:          []time.Time{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Now()},
+			RawMode:            mode,
+			Sidecars:           true,
+		}
+
+		ctx := context.Background()
+		client := probeFakeCamera(camera)
+		info, err := client.Info(ctx)
+		require.NoError(t, err)
+		s := &connectSession{ip: camera.host(), client: client, info: info, verType: V2}
+
+		progress := mpb.New(mpb.WithOutput(io.Discard))
+		result, err := s.importMedia(ctx, params, progress, "HERO12 Black", "")
+		progress.Shutdown()
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+
+		matches, err := filepath.Glob(filepath.Join(output, "*", "photos"))
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		imported := []string{}
+		require.NoError(t, filepath.Walk(matches[0], func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				relative, _ := filepath.Rel(matches[0], path)
+				imported = append(imported, filepath.ToSlash(relative))
+			}
+			return err
+		}))
+		require.Equal(t, expected, imported, mode)
+
+		// the metadata of a photo is only read for its sidecar, once per sidecar written
+		sidecars, lookups := 0, 0
+		for _, name := range expected {
+			if strings.HasSuffix(name, ".xmp") {
+				sidecars++
+			}
+		}
+		for _, request := range camera.requests {
+			if strings.HasPrefix(request, "/gopro/media/info") && strings.Contains(request, "GOPR0002") {
+				lookups++
+			}
+		}
+		require.Equal(t, sidecars, lookups, mode)
+
+		sidecar, err := os.ReadFile(filepath.Join(matches[0], "GOPR0002.xmp"))
+		require.NoError(t, err)
+		require.Contains(t, string(sidecar), `tiff:Model="HERO12 Black"`)
+		require.Contains(t, string(sidecar), `xmp:Rating="2"`)
+	}
+}
+
+func TestImportSidecars(t *testing.T) {
+	// merging needs ffmpeg, chapters are simply joined here
+	mergeChapters = func(chapters []string, keep bool, progressBar *mpb.Progress) error {
+		merged := []byte{}
+		for _, chapter := range chapters {
+			content, err := os.ReadFile(chapter)
+			if err != nil {
+				return err
+			}
+			merged = append(merged, content...)
+		}
+		if err := os.WriteFile(MergedChapterName(chapters), merged, 0o600); err != nil {
+			return err
+		}
+		for _, chapter := range chapters {
+			if !keep {
+				os.Remove(chapter)
+			}
+		}
+		return nil
+	}
+	t.Cleanup(func() { mergeChapters = mergeChapterSet })
+
+	for _, merge := range []bool{false, true} {
+		camera := newFakeCamera(t, true)
+		camera.hilights["GX010001.MP4"] = []int{1000, 4000, 9000}
+		camera.files["GX020001.MP4"] = []byte("chapter")
+		camera.hilights["GX020001.MP4"] = []int{2000, 3000, 5000}
+
+		output := t.TempDir()
+		params := utils.ImportParams{
+			Output:             output,
+			SkipAuxiliaryFiles: true,
+			Sidecars:           true,
+			DateFormat:         "dd-mm-yyyy",
+			DateRange:          []time.Time{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Now()},
+			Chapters:           utils.ChapterOptions{Merge: merge},
+		}
+
+		ctx := context.Background()
+		client := probeFakeCamera(camera)
+		info, err := client.Info(ctx)
+		require.NoError(t, err)
+		s := &connectSession{ip: camera.host(), client: client, info: info, verType: V2}
+
+		progress := mpb.New(mpb.WithOutput(io.Discard))
+		result, err := s.importMedia(ctx, params, progress, "HERO12 Black", "")
+		progress.Shutdown()
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+
+		photos, err := filepath.Glob(filepath.Join(output, "*", "photos", "GOPR0002.xmp"))
+		require.NoError(t, err)
+		require.Len(t, photos, 1)
+
+		chapters, err := filepath.Glob(filepath.Join(output, "*", "videos", "*", "GX0001-0?.xmp"))
+		require.NoError(t, err)
+		merged, err := filepath.Glob(filepath.Join(output, "*", "videos", "*", "GX0001.xmp"))
+		require.NoError(t, err)
+		if !merge {
+			require.Len(t, chapters, 2)
+			require.Empty(t, merged)
+			sidecar, err := os.ReadFile(chapters[0])
+			require.NoError(t, err)
+			require.Contains(t, string(sidecar), `tiff:Make="GoPro"`)
+			require.Contains(t, string(sidecar), `aux:SerialNumber="C3501324500001"`)
+			require.Contains(t, string(sidecar), `xmp:Rating="3"`)
+			continue
+		}
+
+		// the sidecars of the chapters go with them, the merged recording has its own rated on all of its HiLights
+		require.Empty(t, chapters)
+		require.Len(t, merged, 1)
+		sidecar, err := os.ReadFile(merged[0])
+		require.NoError(t, err)
+		require.Contains(t, string(sidecar), `aux:SerialNumber="C3501324500001"`)
+		require.Contains(t, string(sidecar), `xmp:Rating="5"`)
+	}
+}
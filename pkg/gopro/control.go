@@ -0,0 +1,258 @@
+package gopro
+
+/* Remote control of cameras over GoPro Connect */
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+)
+
+type Preset string
+
+const (
+	PresetVideo     Preset = "video"
+	PresetPhoto     Preset = "photo"
+	PresetTimelapse Preset = "timelapse"
+)
+
+var presetGroups = map[Preset]int{
+	PresetVideo:     1000,
+	PresetPhoto:     1001,
+	PresetTimelapse: 1002,
+}
+
+// legacy cameras only know modes, timelapse lives under multishot
+var presetModes = map[Preset]int{
+	PresetVideo:     0,
+	PresetPhoto:     1,
+	PresetTimelapse: 2,
+}
+
+type namedSetting struct {
+	ID      int
+	Options map[string]int
+}
+
+// settings and options share IDs between gpControl and Open GoPro
+var namedSettings = map[string]namedSetting{
+	"resolution": {ID: 2, Options: map[string]int{
+		"4k": 1, "2.7k": 4, "2.7k-4:3": 6, "1440": 7, "1080": 9, "4k-4:3": 18, "5k": 24, "5k-4:3": 25, "5.3k": 100, "5.3k-8:7": 107, "4k-8:7": 108,
+	}},
+	"fps": {ID: 3, Options: map[string]int{
+		"240": 0, "120": 1, "100": 2, "60": 5, "50": 6, "30": 8, "25": 9, "24": 10, "200": 13,
+	}},
+	"lens": {ID: 121, Options: map[string]int{
+		"wide": 0, "narrow": 2, "superview": 3, "linear": 4, "max-superview": 7, "linear+horizon": 8, "hyperview": 9, "linear+horizon-lock": 10,
+	}},
+	"hypersmooth": {ID: 135, Options: map[string]int{
+		"off": 0, "on": 1, "high": 2, "boost": 3, "auto": 4, "standard": 100,
+	}},
+	"auto-off": {ID: 59, Options: map[string]int{
+		"never": 0, "1min": 1, "5min": 4, "15min": 6, "30min": 7,
+	}},
+	"gps": {ID: 83, Options: map[string]int{
+		"off": 0, "on": 1,
+	}},
+}
+
+// ParseSetting turns <setting>=<value> into numeric IDs, either side may be a known name or a raw number
+func ParseSetting(pair string) (int, int, error) {
+	name, value, found := strings.Cut(pair, "=")
+	if !found {
+		return 0, 0, mErrors.ErrInvalidSuppliedData(pair)
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	options := map[string]int{}
+	id, err := strconv.Atoi(name)
+	if err != nil {
+		setting, ok := namedSettings[name]
+		if !ok {
+			return 0, 0, mErrors.ErrInvalidSuppliedData(name)
+		}
+		id = setting.ID
+		options = setting.Options
+	} else {
+		for _, setting := range namedSettings {
+			if setting.ID == id {
+				options = setting.Options
+			}
+		}
+	}
+
+	if option, ok := options[value]; ok {
+		return id, option, nil
+	}
+	option, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, 0, mErrors.ErrInvalidSuppliedData(value)
+	}
+	return id, option, nil
+}
+
+type cameraState struct {
+	Status   map[string]interface{} `json:"status"`
+	Settings map[string]interface{} `json:"settings"`
+}
+
+func (s cameraState) status(id int) int64 {
+	switch value := s.Status[strconv.Itoa(id)].(type) {
+	case float64:
+		return int64(value)
+	case string:
+		i, _ := strconv.ParseInt(value, 10, 64)
+		return i
+	}
+	return 0
+}
+
+type CameraStatus struct {
+	Battery        int
+	Recording      bool
+	Busy           bool
+	RemainingSpace int64
+	Videos         int
+	Photos         int
+}
+
+func (s cameraState) parse() CameraStatus {
+	return CameraStatus{
+		Battery:        int(s.status(70)),
+		Busy:           s.status(8) == 1,
+		Recording:      s.status(10) == 1,
+		RemainingSpace: s.status(54) * 1024,
+		Photos:         int(s.status(38)),
+		Videos:         int(s.status(39)),
+	}
+}
+
+func (c legacyClient) State(ctx context.Context) (*cameraState, error) {
+	state := &cameraState{}
+	err := caller(ctx, c.host, "gp/gpControl/status", state)
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (c legacyClient) Shutter(ctx context.Context, start bool) error {
+	return caller(ctx, c.host, fmt.Sprintf("gp/gpControl/command/shutter?p=%d", onOff(start)), nil)
+}
+
+func (c legacyClient) Preset(ctx context.Context, preset Preset) error {
+	mode, ok := presetModes[preset]
+	if !ok {
+		return mErrors.ErrInvalidSuppliedData(string(preset))
+	}
+	return caller(ctx, c.host, fmt.Sprintf("gp/gpControl/command/mode?p=%d", mode), nil)
+}
+
+func (c legacyClient) Setting(ctx context.Context, setting, option int) error {
+	return caller(ctx, c.host, fmt.Sprintf("gp/gpControl/setting/%d/%d", setting, option), nil)
+}
+
+// DateTime sends each field as a hex encoded byte, eg: %17%0a%13%0e%1e%00 for 2023-10-19 14:30:00
+func (c legacyClient) DateTime(ctx context.Context, t time.Time) error {
+	return caller(ctx, c.host, fmt.Sprintf("gp/gpControl/command/setup/date_time?p=%%%02x%%%02x%%%02x%%%02x%%%02x%%%02x",
+		t.Year()-2000, int(t.Month()), t.Day(), t.Hour(), t.Minute(), t.Second()), nil)
+}
+
+func (c legacyClient) Sleep(ctx context.Context) error {
+	return caller(ctx, c.host, "gp/gpControl/command/system/sleep", nil)
+}
+
+func (c openGoProClient) State(ctx context.Context) (*cameraState, error) {
+	state := &cameraState{}
+	err := caller(ctx, c.host, "gopro/camera/state", state)
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (c openGoProClient) Shutter(ctx context.Context, start bool) error {
+	action := "stop"
+	if start {
+		action = "start"
+	}
+	return caller(ctx, c.host, "gopro/camera/shutter/"+action, nil)
+}
+
+func (c openGoProClient) Preset(ctx context.Context, preset Preset) error {
+	group, ok := presetGroups[preset]
+	if !ok {
+		return mErrors.ErrInvalidSuppliedData(string(preset))
+	}
+	return caller(ctx, c.host, fmt.Sprintf("gopro/camera/presets/set_group?id=%d", group), nil)
+}
+
+func (c openGoProClient) Setting(ctx context.Context, setting, option int) error {
+	return caller(ctx, c.host, fmt.Sprintf("gopro/camera/setting?setting=%d&option=%d", setting, option), nil)
+}
+
+func (c openGoProClient) DateTime(ctx context.Context, t time.Time) error {
+	return caller(ctx, c.host, fmt.Sprintf("gopro/camera/set_date_time?date=%d_%d_%d&time=%d_%d_%d",
+		t.Year(), int(t.Month()), t.Day(), t.Hour(), t.Minute(), t.Second()), nil)
+}
+
+// Open GoPro only exposes sleep over BLE
+func (c openGoProClient) Sleep(ctx context.Context) error {
+	return mErrors.ErrUnsupportedCommand("sleep", openGoProAPI)
+}
+
+// CameraControl drives a single camera over GoPro Connect
+type CameraControl struct {
+	Device  ConnectDevice
+	client  connectClient
+	verType Type
+}
+
+func NewCameraControl(ctx context.Context, ip string) (*CameraControl, error) {
+	s, err := newConnectSession(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+	return &CameraControl{Device: ConnectDevice{IP: ip, Info: *s.info}, client: s.client, verType: s.verType}, nil
+}
+
+func (c *CameraControl) Name() string {
+	return fmt.Sprintf("%s (%s)", c.Device.Info.Info.ModelName, c.Device.IP)
+}
+
+func (c *CameraControl) Status(ctx context.Context) (CameraStatus, error) {
+	state, err := c.client.State(ctx)
+	if err != nil {
+		return CameraStatus{}, err
+	}
+	return state.parse(), nil
+}
+
+func (c *CameraControl) Shutter(ctx context.Context, start bool) error {
+	return c.client.Shutter(ctx, start)
+}
+
+func (c *CameraControl) Preset(ctx context.Context, name string) error {
+	return c.client.Preset(ctx, Preset(strings.ToLower(name)))
+}
+
+func (c *CameraControl) Set(ctx context.Context, pair string) error {
+	setting, option, err := ParseSetting(pair)
+	if err != nil {
+		return err
+	}
+	return c.client.Setting(ctx, setting, option)
+}
+
+func (c *CameraControl) SyncDateTime(ctx context.Context, t time.Time) error {
+	return c.client.DateTime(ctx, t)
+}
+
+func (c *CameraControl) Sleep(ctx context.Context) error {
+	return c.client.Sleep(ctx)
+}
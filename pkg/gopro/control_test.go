@@ -0,0 +1,81 @@
+package gopro
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSetting(t *testing.T) {
+	for pair, expected := range map[string][2]int{
+		"resolution=4k":    {2, 1},
+		"fps=60":           {3, 5},
+		"Lens = Linear":    {121, 4},
+		"hypersmooth=auto": {135, 4},
+		"121=8":            {121, 8},
+		"2=5.3k":           {2, 100},
+		"999=3":            {999, 3},
+	} {
+		setting, option, err := ParseSetting(pair)
+		require.NoError(t, err, pair)
+		require.Equal(t, expected, [2]int{setting, option}, pair)
+	}
+
+	for _, pair := range []string{"resolution", "colour=flat", "fps=fast"} {
+		_, _, err := ParseSetting(pair)
+		require.Error(t, err, pair)
+	}
+}
+
+func TestCameraControl(t *testing.T) {
+	date := time.Date(2023, time.October, 19, 14, 30, 5, 0, time.Local)
+	for _, test := range []struct {
+		openGoPro bool
+		mode      string
+		dateTime  string
+	}{
+		{openGoPro: true, mode: "1002", dateTime: "2023_10_19 14_30_5"},
+		{openGoPro: false, mode: "2", dateTime: "p=%17%0a%13%0e%1e%05"},
+	} {
+		camera := newFakeCamera(t, test.openGoPro)
+		control := &CameraControl{client: probeFakeCamera(camera)}
+		ctx := context.Background()
+
+		require.NoError(t, control.Shutter(ctx, true))
+		status, err := control.Status(ctx)
+		require.NoError(t, err)
+		require.True(t, status.Recording)
+		require.Equal(t, 87, status.Battery)
+		require.Equal(t, int64(1024*1024), status.RemainingSpace)
+
+		require.NoError(t, control.Shutter(ctx, false))
+		status, err = control.Status(ctx)
+		require.NoError(t, err)
+		require.False(t, status.Recording)
+
+		require.NoError(t, control.Preset(ctx, "Timelapse"))
+		require.Equal(t, test.mode, camera.mode)
+		require.Error(t, control.Preset(ctx, "slowmo"))
+
+		require.NoError(t, control.Set(ctx, "lens=linear"))
+		require.Equal(t, "4", camera.settings["121"])
+
+		require.NoError(t, control.SyncDateTime(ctx, date))
+		require.Equal(t, test.dateTime, camera.dateTime)
+
+		if !test.openGoPro {
+			require.NoError(t, control.Sleep(ctx))
+			require.True(t, camera.asleep)
+			continue
+		}
+		// sleep is BLE only on Open GoPro, nothing falls back to gpControl
+		require.Error(t, control.Sleep(ctx))
+		require.False(t, camera.asleep)
+		for _, request := range camera.requests {
+			require.False(t, strings.HasPrefix(request, "/gp/"), request)
+		}
+	}
+}
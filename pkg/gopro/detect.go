@@ -19,15 +19,28 @@ func Detect() (string, utils.ConnectionType, error) {
 		}
 	}
 
-	ctx := context.Background()
-	networkDevices, err := GetGoProNetworkAddresses(ctx)
+	ips, err := DetectConnect()
 	if err != nil {
 		return "", "", err
 	}
 
-	if len(networkDevices) > 0 {
-		return networkDevices[0].IP, utils.Connect, nil
+	if len(ips) > 0 {
+		return ips[0], utils.Connect, nil
 	}
 
 	return "", "", mErrors.ErrNoCameraDetected
 }
+
+// DetectConnect returns the address of every GoPro attached via Connect
+func DetectConnect() ([]string, error) {
+	ctx := context.Background()
+	networkDevices, err := GetGoProNetworkAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ips := []string{}
+	for _, device := range networkDevices {
+		ips = append(ips, device.IP)
+	}
+	return ips, nil
+}
@@ -0,0 +1,333 @@
+package gopro
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCamera serves enough of the gpControl or Open GoPro API to exercise the Connect clients
+type fakeCamera struct {
+	*httptest.Server
+	openGoPro bool
+
+	mu       sync.Mutex
+	turbo    bool
+	files    map[string][]byte
+	created  map[string]int64
+	groups   map[string]fakeGroup
+	hilights map[string][]int
+	requests []string
+
+	recording bool
+	mode      string
+	settings  map[string]string
+	dateTime  string
+	asleep    bool
+}
+
+const fakeFolder = "100GOPRO"
+
+type fakeGroup struct {
+	kind        string
+	first, last int
+}
+
+func newFakeCamera(t *testing.T, openGoPro bool) *fakeCamera {
+	t.Helper()
+	camera := &fakeCamera{
+		openGoPro: openGoPro,
+		files: map[string][]byte{
+			"GX010001.MP4": []byte("video"),
+			"GL010001.LRV": []byte("proxy"),
+			"GOPR0002.JPG": []byte("photo"),
+		},
+		created:  map[string]int64{},
+		groups:   map[string]fakeGroup{},
+		hilights: map[string][]int{},
+		settings: map[string]string{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/videos/DCIM/", camera.download)
+	if openGoPro {
+		mux.HandleFunc("/gopro/camera/state", camera.state)
+		mux.HandleFunc("/gopro/camera/info", camera.json(map[string]interface{}{
+			"model_number":     62,
+			"model_name":       "HERO12 Black",
+			"firmware_version": "H23.01.01.10.00",
+			"serial_number":    "C3501324500001",
+			"ap_mac_addr":      "2674f7f65f78",
+			"ap_ssid":          "GP24500001",
+		}))
+		mux.HandleFunc("/gopro/media/list", camera.mediaList)
+		mux.HandleFunc("/gopro/media/info", camera.mediaInfo("path"))
+		mux.HandleFunc("/gopro/media/turbo_transfer", camera.setTurbo)
+		mux.HandleFunc("/gopro/media/hilight/file", camera.hilight("path", "ms"))
+		mux.HandleFunc("/gopro/camera/shutter/start", camera.record(true))
+		mux.HandleFunc("/gopro/camera/shutter/stop", camera.record(false))
+		mux.HandleFunc("/gopro/camera/presets/set_group", camera.control(func(r *http.Request) {
+			camera.mode = r.URL.Query().Get("id")
+		}))
+		mux.HandleFunc("/gopro/camera/setting", camera.control(func(r *http.Request) {
+			camera.settings[r.URL.Query().Get("setting")] = r.URL.Query().Get("option")
+		}))
+		mux.HandleFunc("/gopro/media/delete/file", camera.delete("path"))
+		mux.HandleFunc("/gopro/media/delete/group", camera.deleteGroup)
+		mux.HandleFunc("/gopro/camera/set_date_time", camera.control(func(r *http.Request) {
+			camera.dateTime = r.URL.Query().Get("date") + " " + r.URL.Query().Get("time")
+		}))
+	} else {
+		mux.HandleFunc("/gp/gpControl/info", camera.json(map[string]interface{}{"info": map[string]interface{}{
+			"model_number":     24,
+			"model_name":       "HERO7 Black",
+			"firmware_version": "HD7.01.01.90.00",
+			"serial_number":    "C3251324500001",
+		}}))
+		mux.HandleFunc("/gp/gpMediaList", camera.mediaList)
+		mux.HandleFunc("/gp/gpMediaMetadata", camera.mediaInfo("p"))
+		mux.HandleFunc("/gp/gpTurbo", camera.setTurbo)
+		mux.HandleFunc("/gp/gpControl/command/storage/tag_moment/playback", camera.hilight("p", "tag"))
+		mux.HandleFunc("/gp/gpControl/status", camera.state)
+		mux.HandleFunc("/gp/gpControl/command/storage/delete", camera.delete("p"))
+		mux.HandleFunc("/gp/gpControl/command/shutter", camera.control(func(r *http.Request) {
+			camera.recording = r.URL.Query().Get("p") == "1"
+		}))
+		mux.HandleFunc("/gp/gpControl/command/mode", camera.control(func(r *http.Request) {
+			camera.mode = r.URL.Query().Get("p")
+		}))
+		mux.HandleFunc("/gp/gpControl/setting/", camera.control(func(r *http.Request) {
+			setting, option, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/gp/gpControl/setting/"), "/")
+			camera.settings[setting] = option
+		}))
+		mux.HandleFunc("/gp/gpControl/command/setup/date_time", camera.control(func(r *http.Request) {
+			camera.dateTime = r.URL.RawQuery
+		}))
+		mux.HandleFunc("/gp/gpControl/command/storage/delete/all", camera.control(func(r *http.Request) {
+			camera.files = map[string][]byte{}
+			camera.groups = map[string]fakeGroup{}
+		}))
+		mux.HandleFunc("/gp/gpControl/command/system/sleep", camera.control(func(r *http.Request) {
+			camera.asleep = true
+		}))
+	}
+	camera.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		camera.mu.Lock()
+		camera.requests = append(camera.requests, r.URL.RequestURI())
+		camera.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(camera.Close)
+	return camera
+}
+
+func (c *fakeCamera) host() string {
+	return strings.TrimPrefix(c.URL, "http://")
+}
+
+func (c *fakeCamera) json(object interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(object)
+	}
+}
+
+func (c *fakeCamera) mediaList(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	files := []map[string]string{}
+	grouped := map[string]bool{}
+	for name, group := range c.groups {
+		for i := group.first; i <= group.last; i++ {
+			grouped[fmt.Sprintf("%s%04d.JPG", sequenceGroup(name), i)] = true
+		}
+		files = append(files, map[string]string{
+			"n":   name,
+			"cre": "1672490791",
+			"mod": "1672490791",
+			"s":   "0",
+			"g":   name[1:4],
+			"b":   strconv.Itoa(group.first),
+			"l":   strconv.Itoa(group.last),
+			"t":   group.kind,
+		})
+	}
+	for name, content := range c.files {
+		// raws are listed as a flag of their JPEG
+		if grouped[name] || strings.HasSuffix(name, ".GPR") {
+			continue
+		}
+		file := map[string]string{
+			"n":   name,
+			"cre": "1672490791",
+			"mod": "1672490791",
+			"s":   strconv.Itoa(len(content)),
+		}
+		if _, ok := c.files[strings.TrimSuffix(name, ".JPG")+".GPR"]; ok && strings.HasSuffix(name, ".JPG") {
+			file["raw"] = "1"
+		}
+		files = append(files, file)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":    "1",
+		"media": []map[string]interface{}{{"d": fakeFolder, "fs": files}},
+	})
+}
+
+func (c *fakeCamera) lookup(path string) (string, bool) {
+	folder, file, found := strings.Cut(path, "/")
+	if !found || folder != fakeFolder {
+		return "", false
+	}
+	_, ok := c.files[file]
+	return file, ok
+}
+
+func (c *fakeCamera) mediaInfo(pathParam string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		file, ok := c.lookup(r.URL.Query().Get(pathParam))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		hilights := c.hilights[file]
+		if hilights == nil {
+			hilights = []int{}
+		}
+		created := c.created[file]
+		if created == 0 {
+			created = 1672490791
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"cre":       strconv.FormatInt(created, 10),
+			"s":         strconv.Itoa(len(c.files[file])),
+			"hi":        hilights,
+			"hc":        strconv.Itoa(len(hilights)),
+			"dur":       "10",
+			"w":         "1920",
+			"h":         "1080",
+			"fps":       "30000",
+			"fps_denom": "1001",
+		})
+	}
+}
+
+func (c *fakeCamera) setTurbo(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.turbo = r.URL.Query().Get("p") == "1"
+	fmt.Fprint(w, "{}")
+}
+
+func (c *fakeCamera) hilight(pathParam, msParam string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		file, ok := c.lookup(r.URL.Query().Get(pathParam))
+		ms, err := strconv.Atoi(r.URL.Query().Get(msParam))
+		if !ok || err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		c.hilights[file] = append(c.hilights[file], ms)
+		fmt.Fprint(w, "{}")
+	}
+}
+
+func (c *fakeCamera) download(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	file, ok := c.lookup(strings.TrimPrefix(r.URL.Path, "/videos/DCIM/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(c.files[file])))
+	_, _ = w.Write(c.files[file])
+}
+
+func (c *fakeCamera) control(apply func(r *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		apply(r)
+		fmt.Fprint(w, "{}")
+	}
+}
+
+func (c *fakeCamera) record(recording bool) http.HandlerFunc {
+	return c.control(func(r *http.Request) {
+		c.recording = recording
+	})
+}
+
+func (c *fakeCamera) state(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	recording := 0
+	if c.recording {
+		recording = 1
+	}
+	status := map[string]interface{}{
+		"8":  0,
+		"10": recording,
+		"38": 1,
+		"39": 1,
+		"54": 1024,
+		"70": 87,
+	}
+	if !c.openGoPro {
+		// gpControl mixes strings in with numbers
+		status["54"] = "1024"
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "settings": c.settings})
+}
+
+func (c *fakeCamera) delete(pathParam string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		file, ok := c.lookup(r.URL.Query().Get(pathParam))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		delete(c.files, file)
+		// the raw of a photo goes with it
+		delete(c.files, strings.Replace(file, ".JPG", ".GPR", 1))
+		fmt.Fprint(w, "{}")
+	}
+}
+
+func (c *fakeCamera) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, name, _ := strings.Cut(r.URL.Query().Get("path"), "/")
+	group, ok := c.groups[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	for i := group.first; i <= group.last; i++ {
+		delete(c.files, fmt.Sprintf("%s%04d.JPG", sequenceGroup(name), i))
+	}
+	delete(c.groups, name)
+	fmt.Fprint(w, "{}")
+}
+
+// addSequence stores frames first..last of a multishot group, one second apart
+func (c *fakeCamera) addSequence(name, kind string, first, last int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.groups[name] = fakeGroup{kind: kind, first: first, last: last}
+	for i := first; i <= last; i++ {
+		frame := fmt.Sprintf("%s%04d.JPG", sequenceGroup(name), i)
+		c.files[frame] = []byte("frame")
+		c.created[frame] = 1672490791 + int64(i-first)
+	}
+}
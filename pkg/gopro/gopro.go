@@ -115,17 +115,17 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 
 	switch root {
 	case "HD6", "HD7", "HD8", "H19", "HD9", "H21", "H22", "H23":
-		result := importFromGoProV2(params)
+		result := importFromGoProV2(params, gpVersion.CameraSerialNumber)
 		return &result, nil
 	case "HD2", "HD3", "HD4", "HX", "HD5":
-		result := importFromGoProV1(params)
+		result := importFromGoProV1(params, gpVersion.CameraSerialNumber)
 		return &result, nil
 	default:
 		return nil, mErrors.ErrUnsupportedCamera(gpVersion.CameraType)
 	}
 }
 
-func importFromGoProV2(params utils.ImportParams) utils.Result {
+func importFromGoProV2(params utils.ImportParams, serial string) utils.Result {
 	fileTypes := FileTypeMatches[V2]
 	var result utils.Result
 
@@ -141,6 +141,8 @@ func importFromGoProV2(params utils.ImportParams) utils.Result {
 		mpb.WithRefreshRate(180*time.Millisecond))
 
 	inlineCounter := utils.ResultCounter{}
+	sequences := sequenceFolders{}
+	chapters := chapterSets{}
 
 folderLoop:
 	for _, f := range folders {
@@ -158,6 +160,9 @@ folderLoop:
 					if !ftype.Regex.MatchString(de.Name()) {
 						continue fileTypeLoop
 					}
+					if ftype.Type == Video {
+						chapters.see(de.Name())
+					}
 
 					d := getFileTime(osPathname, true)
 					mediaDate := getMediaDate(getFileTime(osPathname, true), params.DateFormat)
@@ -171,6 +176,10 @@ folderLoop:
 						return godirwalk.SkipThis
 					}
 
+					if (ftype.Type == Photo || ftype.Type == RawPhoto) && !params.RawMode.KeepFile(osPathname) {
+						return godirwalk.SkipThis
+					}
+
 					dayFolder := utils.GetOrder(params.Sort, locationService, osPathname, params.Output, mediaDate, params.CameraName)
 
 					wg.Add(1)
@@ -189,9 +198,15 @@ folderLoop:
 							additionalDir = "360"
 						}
 
+						sidecar := utils.XMP{Model: params.CameraName, SerialNumber: serial, CreateDate: d}
 						if hilights, err := GetHiLights(osPathname); err == nil {
+							sidecar.Rating = utils.HiLightRating(len(hilights.Timestamps))
 							if durationResp, err := ffprobe.Duration(osPathname); err == nil {
-								additionalDir = filepath.Join(additionalDir, getImportanceName(hilights.Timestamps, int(durationResp.Streams[0].Duration), params.TagNames))
+								importance := getImportanceName(hilights.Timestamps, int(durationResp.Streams[0].Duration), params.TagNames)
+								additionalDir = filepath.Join(additionalDir, importance)
+								if importance != "" {
+									sidecar.Keywords = []string{importance}
+								}
 							}
 						}
 						folder := filepath.Join(dayFolder, "videos", additionalDir, rfpsFolder)
@@ -202,6 +217,11 @@ folderLoop:
 								inlineCounter.SetFailure(err, filename)
 							} else {
 								inlineCounter.SetSuccess()
+								chapters.add(filepath.Join(folder, filename))
+								if params.Sidecars {
+									chapters.addSidecar(filepath.Join(folder, filename), sidecar)
+									writeSidecar(filepath.Join(folder, filename), sidecar, &inlineCounter)
+								}
 							}
 						}(folder, filename, osPathname, bar)
 
@@ -237,6 +257,9 @@ folderLoop:
 								inlineCounter.SetFailure(err, filename)
 							} else {
 								inlineCounter.SetSuccess()
+								if params.Sidecars && params.RawMode.WritesSidecarFile(osPathname) {
+									writeSidecar(filepath.Join(folder, filename), utils.XMP{Model: params.CameraName, SerialNumber: serial, CreateDate: d}, &inlineCounter)
+								}
 							}
 						}(folder, de.Name(), osPathname, bar)
 
@@ -245,7 +268,8 @@ folderLoop:
 						if !ftype.HeroMode {
 							additionalDir = "360"
 						}
-						folder := filepath.Join(dayFolder, "multishot", additionalDir, de.Name()[:4])
+						folder := filepath.Join(dayFolder, "multishot", additionalDir, sequenceGroup(de.Name()))
+						sequences.add(folder, "")
 						go func(folder, filename, osPathname string, bar *mpb.Bar) {
 							defer wg.Done()
 							err := parse(folder, filename, osPathname, params.BufferSize, bar, d)
@@ -257,7 +281,7 @@ folderLoop:
 						}(folder, de.Name(), osPathname, bar)
 
 					case RawPhoto:
-						folder := filepath.Join(dayFolder, "photos/raw")
+						folder := params.RawMode.RawFolder(filepath.Join(dayFolder, "photos"))
 						go func(folder, filename, osPathname string, bar *mpb.Bar) {
 							defer wg.Done()
 							err := parse(folder, filename, osPathname, params.BufferSize, bar, d)
@@ -265,6 +289,7 @@ folderLoop:
 								inlineCounter.SetFailure(err, filename)
 							} else {
 								inlineCounter.SetSuccess()
+								writeSidecar(filepath.Join(folder, filename), utils.XMP{Model: params.CameraName, SerialNumber: serial, CreateDate: d}, &inlineCounter)
 							}
 						}(folder, de.Name(), osPathname, bar)
 
@@ -295,6 +320,8 @@ folderLoop:
 	}
 
 	wg.Wait()
+	sequences.finish(params.Sequences, progressBar, &inlineCounter)
+	chapters.merge(params.Chapters, progressBar, &inlineCounter)
 	progressBar.Shutdown()
 
 	result.Errors = append(result.Errors, inlineCounter.Get().Errors...)
@@ -304,7 +331,7 @@ folderLoop:
 	return result
 }
 
-func importFromGoProV1(params utils.ImportParams) utils.Result {
+func importFromGoProV1(params utils.ImportParams, serial string) utils.Result {
 	fileTypes := FileTypeMatches[V1]
 	var result utils.Result
 
@@ -320,6 +347,8 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 		mpb.WithRefreshRate(180*time.Millisecond))
 
 	inlineCounter := utils.ResultCounter{}
+	sequences := sequenceFolders{}
+	chapters := chapterSets{}
 
 	for _, f := range folders {
 		r := MediaFolderRegex.MatchString(f.Name())
@@ -335,6 +364,9 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 					if !ftype.Regex.MatchString(de.Name()) {
 						continue
 					}
+					if ftype.Type == Video || ftype.Type == ChapteredVideo {
+						chapters.see(de.Name())
+					}
 
 					d := getFileTime(osPathname, true)
 					mediaDate := getMediaDate(d, params.DateFormat)
@@ -348,6 +380,10 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 						return godirwalk.SkipThis
 					}
 
+					if (ftype.Type == Photo || ftype.Type == RawPhoto) && !params.RawMode.KeepFile(osPathname) {
+						return godirwalk.SkipThis
+					}
+
 					wg.Add(1)
 					bar := utils.GetNewBar(progressBar, info.Size(), de.Name(), utils.IoTX)
 
@@ -370,9 +406,15 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 						rfpsFolder := fmt.Sprintf("%dx%d %s", s.Streams[0].Width, s.Streams[0].Height, framerate)
 
 						additionalDir := ""
+						sidecar := utils.XMP{Model: params.CameraName, SerialNumber: serial, CreateDate: d}
 						if hilights, err := GetHiLights(osPathname); err == nil {
+							sidecar.Rating = utils.HiLightRating(len(hilights.Timestamps))
 							if durationResp, err := ffprobe.Duration(osPathname); err == nil {
-								additionalDir = filepath.Join(additionalDir, getImportanceName(hilights.Timestamps, int(durationResp.Streams[0].Duration), params.TagNames))
+								importance := getImportanceName(hilights.Timestamps, int(durationResp.Streams[0].Duration), params.TagNames)
+								additionalDir = filepath.Join(additionalDir, importance)
+								if importance != "" {
+									sidecar.Keywords = []string{importance}
+								}
 							}
 						}
 
@@ -384,6 +426,11 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 								inlineCounter.SetFailure(err, filename)
 							} else {
 								inlineCounter.SetSuccess()
+								chapters.add(filepath.Join(folder, filename))
+								if params.Sidecars {
+									chapters.addSidecar(filepath.Join(folder, filename), sidecar)
+									writeSidecar(filepath.Join(folder, filename), sidecar, &inlineCounter)
+								}
 							}
 						}(folder, x, osPathname, bar)
 
@@ -417,9 +464,15 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 						rfpsFolder := fmt.Sprintf("%dx%d %s", s.Streams[0].Width, s.Streams[0].Height, framerate)
 
 						additionalDir := ""
+						sidecar := utils.XMP{Model: params.CameraName, SerialNumber: serial, CreateDate: d}
 						if hilights, err := GetHiLights(osPathname); err == nil {
+							sidecar.Rating = utils.HiLightRating(len(hilights.Timestamps))
 							if durationResp, err := ffprobe.Duration(osPathname); err == nil {
-								additionalDir = filepath.Join(additionalDir, getImportanceName(hilights.Timestamps, int(durationResp.Streams[0].Duration), params.TagNames))
+								importance := getImportanceName(hilights.Timestamps, int(durationResp.Streams[0].Duration), params.TagNames)
+								additionalDir = filepath.Join(additionalDir, importance)
+								if importance != "" {
+									sidecar.Keywords = []string{importance}
+								}
 							}
 						}
 
@@ -431,6 +484,11 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 								inlineCounter.SetFailure(err, filename)
 							} else {
 								inlineCounter.SetSuccess()
+								chapters.add(filepath.Join(folder, filename))
+								if params.Sidecars {
+									chapters.addSidecar(filepath.Join(folder, filename), sidecar)
+									writeSidecar(filepath.Join(folder, filename), sidecar, &inlineCounter)
+								}
 							}
 						}(folder, name, osPathname, bar)
 
@@ -460,6 +518,9 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 								inlineCounter.SetFailure(err, filename)
 							} else {
 								inlineCounter.SetSuccess()
+								if params.Sidecars && params.RawMode.WritesSidecarFile(osPathname) {
+									writeSidecar(filepath.Join(folder, filename), utils.XMP{Model: params.CameraName, SerialNumber: serial, CreateDate: d}, &inlineCounter)
+								}
 							}
 						}(folder, de.Name(), osPathname, bar)
 
@@ -479,7 +540,8 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 						}(folder, de.Name(), osPathname, bar)
 
 					case Multishot:
-						folder := filepath.Join(dayFolder, "multishot", de.Name()[:4])
+						folder := filepath.Join(dayFolder, "multishot", sequenceGroup(de.Name()))
+						sequences.add(folder, "")
 						go func(folder, filename, osPathname string, bar *mpb.Bar) {
 							defer wg.Done()
 							err := parse(folder, filename, osPathname, params.BufferSize, bar, d)
@@ -491,7 +553,7 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 						}(folder, de.Name(), osPathname, bar)
 
 					case RawPhoto:
-						folder := filepath.Join(dayFolder, "photos/raw")
+						folder := params.RawMode.RawFolder(filepath.Join(dayFolder, "photos"))
 						go func(folder, filename, osPathname string, bar *mpb.Bar) {
 							defer wg.Done()
 							err := parse(folder, filename, osPathname, params.BufferSize, bar, d)
@@ -499,6 +561,7 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 								inlineCounter.SetFailure(err, filename)
 							} else {
 								inlineCounter.SetSuccess()
+								writeSidecar(filepath.Join(folder, filename), utils.XMP{Model: params.CameraName, SerialNumber: serial, CreateDate: d}, &inlineCounter)
 							}
 						}(folder, de.Name(), osPathname, bar)
 
@@ -517,6 +580,8 @@ func importFromGoProV1(params utils.ImportParams) utils.Result {
 	}
 
 	wg.Wait()
+	sequences.finish(params.Sequences, progressBar, &inlineCounter)
+	chapters.merge(params.Chapters, progressBar, &inlineCounter)
 	progressBar.Shutdown()
 
 	result.Errors = append(result.Errors, inlineCounter.Get().Errors...)
@@ -573,6 +638,14 @@ func getMediaDate(d time.Time, dateFormat string) string {
 	return mediaDate
 }
 
+// writeSidecar describes an imported file with an XMP sidecar next to it
+func writeSidecar(path string, sidecar utils.XMP, counter *utils.ResultCounter) {
+	sidecar.Make = "GoPro"
+	if err := utils.WriteSidecar(locationService, path, sidecar); err != nil {
+		counter.SetFailure(err, filepath.Base(utils.SidecarPath(path)))
+	}
+}
+
 func parse(folder string, name string, osPathname string, bufferSize int, bar *mpb.Bar, modTime time.Time) error {
 	if _, err := os.Stat(folder); os.IsNotExist(err) {
 		mkdirerr := os.MkdirAll(folder, 0o755)
@@ -1,6 +1,9 @@
 package gopro
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 func getImportanceName(tags []int, videoDuration int, names []string) string {
 	if videoDuration < 20 || len(names) < 3 {
@@ -25,3 +28,31 @@ func getImportanceName(tags []int, videoDuration int, names []string) string {
 	}
 	return names[howMany-1]
 }
+
+type HiLightClip struct {
+	Index    int
+	Start    float64
+	Duration float64
+}
+
+// GetHiLightClips returns a window around each HiLight tag (in ms), clamped to the video duration (in s)
+func GetHiLightClips(tags []int, before, after, videoDuration float64) []HiLightClip {
+	clips := []HiLightClip{}
+	for index, tag := range tags {
+		at := float64(tag) / 1000
+		start := math.Max(at-before, 0)
+		end := at + after
+		if videoDuration > 0 {
+			end = math.Min(end, videoDuration)
+		}
+		if end <= start {
+			continue
+		}
+		clips = append(clips, HiLightClip{
+			Index:    index + 1,
+			Start:    start,
+			Duration: end - start,
+		})
+	}
+	return clips
+}
@@ -58,3 +58,19 @@ func TestNoneAtAll(t *testing.T) {
 	importanceName := getImportanceName(gpFileInfo.Hi, gpFileInfo.Dur, importanceNames)
 	require.Empty(t, importanceName)
 }
+
+func TestHiLightClips(t *testing.T) {
+	clips := GetHiLightClips([]int{1360, 18880, 23500}, 5, 3, 24)
+	require.Len(t, clips, 3)
+
+	require.Equal(t, 1, clips[0].Index)
+	require.Equal(t, 0.0, clips[0].Start)
+	require.InDelta(t, 4.36, clips[0].Duration, 0.001)
+
+	require.InDelta(t, 13.88, clips[1].Start, 0.001)
+	require.InDelta(t, 8.0, clips[1].Duration, 0.001)
+
+	require.Equal(t, 3, clips[2].Index)
+	require.InDelta(t, 18.5, clips[2].Start, 0.001)
+	require.InDelta(t, 5.5, clips[2].Duration, 0.001)
+}
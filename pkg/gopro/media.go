@@ -0,0 +1,153 @@
+package gopro
+
+/* Managing media stored on a camera over GoPro Connect */
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+)
+
+type CameraMedia struct {
+	Folder   string
+	Name     string
+	Size     int64
+	Created  time.Time
+	Imported string // path of the verified copy in the output folder, if any
+	RawSize  int64  // size of the GPR shot along with the JPG, the camera deletes both together
+}
+
+func (m CameraMedia) Path() string {
+	return m.Folder + "/" + m.Name
+}
+
+// Media lists every file on the camera, multishot groups are expanded into their files
+func (c *CameraControl) Media(ctx context.Context) ([]CameraMedia, error) {
+	gpMediaList, err := c.client.MediaList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	media := []CameraMedia{}
+	for _, folder := range gpMediaList.Media {
+		for _, goprofile := range folder.Fs {
+			created := time.Unix(goprofile.Cre, 0)
+			if goprofile.B == 0 && goprofile.L == 0 {
+				m := CameraMedia{Folder: folder.D, Name: goprofile.N, Size: goprofile.S, Created: created}
+				if goprofile.Raw == "1" {
+					rawSize, err := head(c.client.MediaURL(folder.D, rawName(goprofile.N)))
+					if err != nil {
+						return nil, err
+					}
+					m.RawSize = int64(rawSize)
+				}
+				media = append(media, m)
+				continue
+			}
+			for i := goprofile.B; i <= goprofile.L; i++ {
+				filename := fmt.Sprintf("%s%04d.JPG", sequenceGroup(goprofile.N), i)
+				gpFileInfo, err := c.client.MediaMetadata(ctx, folder.D, filename)
+				if err != nil {
+					return nil, err
+				}
+				media = append(media, CameraMedia{Folder: folder.D, Name: filename, Size: gpFileInfo.S, Created: created})
+			}
+		}
+	}
+	return media, nil
+}
+
+// FindMedia looks up FOLDER/FILE or just FILE in a media list
+func FindMedia(media []CameraMedia, name string) (CameraMedia, bool) {
+	for _, m := range media {
+		if m.Path() == name || m.Name == name {
+			return m, true
+		}
+	}
+	return CameraMedia{}, false
+}
+
+// importedNames are the names a file from this camera can have once imported
+func (c *CameraControl) importedNames(name string) []string {
+	names := []string{name}
+	if strings.EqualFold(filepath.Ext(name), ".MP4") {
+		if renamed := connectVideoName(name, c.verType); renamed != name {
+			names = append(names, renamed)
+		}
+	}
+	return names
+}
+
+func rawName(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".GPR"
+}
+
+/*
+VerifyImported fills in Imported for every file that has a copy of the same name and size under output.
+A JPG shot with a GPR only counts once both were imported, as deleting it on the camera deletes the GPR too.
+*/
+func (c *CameraControl) VerifyImported(media []CameraMedia, output string) error {
+	stat, err := os.Stat(output)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return mErrors.ErrInvalidSuppliedData(output)
+	}
+
+	wanted := map[string]bool{}
+	for _, m := range media {
+		for _, name := range c.importedNames(m.Name) {
+			wanted[name] = true
+		}
+		if m.RawSize > 0 {
+			wanted[rawName(m.Name)] = true
+		}
+	}
+
+	found := map[string][]string{}
+	err = filepath.Walk(output, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && wanted[info.Name()] {
+			key := info.Name() + ":" + strconv.FormatInt(info.Size(), 10)
+			found[key] = append(found[key], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, m := range media {
+		media[i].Imported = ""
+		for _, name := range c.importedNames(m.Name) {
+			if paths, ok := found[name+":"+strconv.FormatInt(m.Size, 10)]; ok {
+				media[i].Imported = paths[0]
+				break
+			}
+		}
+		if _, ok := found[rawName(m.Name)+":"+strconv.FormatInt(m.RawSize, 10)]; m.RawSize > 0 && !ok {
+			media[i].Imported = ""
+		}
+	}
+	return nil
+}
+
+func (c *CameraControl) Delete(ctx context.Context, m CameraMedia) error {
+	return c.client.Delete(ctx, m.Folder, m.Name)
+}
+
+func (c *CameraControl) DeleteAll(ctx context.Context) error {
+	return c.client.DeleteAll(ctx)
+}
+
+func (c *CameraControl) HiLight(ctx context.Context, m CameraMedia, ms int) error {
+	return c.client.HiLight(ctx, m.Folder, m.Name, ms)
+}
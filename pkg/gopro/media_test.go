@@ -0,0 +1,79 @@
+package gopro
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyImportedAndDelete(t *testing.T) {
+	for _, openGoPro := range []bool{true, false} {
+		camera := newFakeCamera(t, openGoPro)
+		control := &CameraControl{client: probeFakeCamera(camera), verType: V2}
+		ctx := context.Background()
+
+		output := t.TempDir()
+		videos := filepath.Join(output, "19-10-2023", "HERO12 Black", "videos", "1920x1080 29")
+		require.NoError(t, os.MkdirAll(videos, 0o755))
+		// renamed on import, same size
+		require.NoError(t, os.WriteFile(filepath.Join(videos, "GX0001-01.MP4"), []byte("video"), 0o600))
+		// same name but truncated
+		require.NoError(t, os.WriteFile(filepath.Join(output, "GOPR0002.JPG"), []byte("ph"), 0o600))
+
+		media, err := control.Media(ctx)
+		require.NoError(t, err)
+		require.Len(t, media, 3)
+		require.NoError(t, control.VerifyImported(media, output))
+
+		video, ok := FindMedia(media, "100GOPRO/GX010001.MP4")
+		require.True(t, ok)
+		require.Equal(t, filepath.Join(videos, "GX0001-01.MP4"), video.Imported)
+		photo, ok := FindMedia(media, "GOPR0002.JPG")
+		require.True(t, ok)
+		require.Empty(t, photo.Imported)
+		_, ok = FindMedia(media, "GX019999.MP4")
+		require.False(t, ok)
+
+		require.NoError(t, control.Delete(ctx, video))
+		require.NotContains(t, camera.files, "GX010001.MP4")
+		require.Error(t, control.Delete(ctx, video))
+
+		require.NoError(t, control.HiLight(ctx, photo, 1000))
+
+		camera.addSequence("G0030001.JPG", "b", 1, 3)
+		camera.files["GOPR0002.GPR"] = []byte("raw")
+		require.NoError(t, control.DeleteAll(ctx))
+		require.Empty(t, camera.files)
+		require.Empty(t, camera.groups)
+	}
+}
+
+func TestVerifyImportedRaw(t *testing.T) {
+	camera := newFakeCamera(t, true)
+	camera.files["GOPR0002.GPR"] = []byte("raw")
+	control := &CameraControl{client: probeFakeCamera(camera), verType: V2}
+	ctx := context.Background()
+
+	media, err := control.Media(ctx)
+	require.NoError(t, err)
+	photo, ok := FindMedia(media, "GOPR0002.JPG")
+	require.True(t, ok)
+	require.Equal(t, int64(3), photo.RawSize)
+
+	// imported with --raw jpeg, deleting the JPG would lose the GPR
+	output := t.TempDir()
+	photos := filepath.Join(output, "19-10-2023", "photos")
+	require.NoError(t, os.MkdirAll(filepath.Join(photos, "raw"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(photos, "GOPR0002.JPG"), []byte("photo"), 0o600))
+	require.NoError(t, control.VerifyImported(media, output))
+	photo, _ = FindMedia(media, "GOPR0002.JPG")
+	require.Empty(t, photo.Imported)
+
+	require.NoError(t, os.WriteFile(filepath.Join(photos, "raw", "GOPR0002.GPR"), []byte("raw"), 0o600))
+	require.NoError(t, control.VerifyImported(media, output))
+	photo, _ = FindMedia(media, "GOPR0002.JPG")
+	require.Equal(t, filepath.Join(photos, "GOPR0002.JPG"), photo.Imported)
+}
@@ -0,0 +1,207 @@
+package gopro
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/fatih/color"
+	mErrors "github.com/konradit/mmt/pkg/errors"
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/konradit/mmt/pkg/videomanipulation"
+	"github.com/vbauerster/mpb/v8"
+)
+
+// a merged recording can be off by about a frame per chapter boundary
+const mergeDurationTolerance = 1.0
+
+// mergeChapters joins the chapters of a recording with ffmpeg, swapped in tests
+var mergeChapters = mergeChapterSet
+
+// chapterSets tracks the chapters found on the camera and the ones imported, to merge complete recordings
+type chapterSets struct {
+	mu       sync.Mutex
+	last     map[string]int
+	imported []string
+	sidecars map[string]utils.XMP
+}
+
+// see records a video present on the camera, whether it gets imported or not
+func (c *chapterSets) see(name string) {
+	info, ok := GetChapterInfo(name)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last == nil {
+		c.last = map[string]int{}
+	}
+	if info.Chapter > c.last[info.Key] {
+		c.last[info.Key] = info.Chapter
+	}
+}
+
+func (c *chapterSets) add(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.imported = append(c.imported, path)
+}
+
+// addSidecar records the sidecar written for an imported chapter, the merged recording gets one made of them
+func (c *chapterSets) addSidecar(path string, sidecar utils.XMP) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sidecars == nil {
+		c.sidecars = map[string]utils.XMP{}
+	}
+	c.sidecars[path] = sidecar
+}
+
+// mergedSidecar describes a recording from the sidecars of its chapters, rated on the HiLights of every chapter
+func (c *chapterSets) mergedSidecar(chapters []string) (utils.XMP, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	merged, ok := c.sidecars[chapters[0]]
+	if !ok {
+		return utils.XMP{}, false
+	}
+	merged.Rating = 0
+	merged.Keywords = nil
+	seen := map[string]bool{}
+	for _, chapter := range chapters {
+		sidecar := c.sidecars[chapter]
+		merged.Rating += sidecar.Rating
+		for _, keyword := range sidecar.Keywords {
+			if !seen[keyword] {
+				seen[keyword] = true
+				merged.Keywords = append(merged.Keywords, keyword)
+			}
+		}
+	}
+	merged.Rating = utils.HiLightRating(merged.Rating)
+	return merged, true
+}
+
+/*
+complete returns recordings with more than one chapter where every chapter on the camera was imported. Chapters
+sorted into different HiLight folders are left apart, the merged recording could only go next to one of them.
+*/
+func (c *chapterSets) complete() [][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	groups, keys := GroupChapters(c.imported)
+	sort.Strings(keys)
+
+	sets := [][]string{}
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) < 2 || len(group) != c.last[key] {
+			continue
+		}
+		complete, together := true, true
+		for i, path := range group {
+			info, _ := GetChapterInfo(path)
+			complete = complete && info.Chapter == i+1
+			together = together && filepath.Dir(path) == filepath.Dir(group[0])
+		}
+		if complete && !together {
+			color.Yellow(">> %s: chapters were sorted into different folders, not merging", filepath.Base(group[0]))
+			continue
+		}
+		if complete {
+			sets = append(sets, group)
+		}
+	}
+	return sets
+}
+
+func verifyMergedDuration(name string, chapters []float64, merged float64) error {
+	expected := 0.0
+	for _, duration := range chapters {
+		expected += duration
+	}
+	if math.Abs(expected-merged) > mergeDurationTolerance {
+		return mErrors.ErrDurationMismatch(name, expected, merged)
+	}
+	return nil
+}
+
+func mergeChapterSet(chapters []string, keep bool, progressBar *mpb.Progress) error {
+	output := MergedChapterName(chapters)
+
+	durations := []float64{}
+	frames := int64(0)
+	for _, chapter := range chapters {
+		durationResp, err := ffprobe.Duration(chapter)
+		if err != nil {
+			return err
+		}
+		framesResp, err := ffprobe.Frames(chapter)
+		if err != nil {
+			return err
+		}
+		durations = append(durations, float64(durationResp.Streams[0].Duration))
+		frames += int64(framesResp.Streams[0].Frames)
+	}
+
+	bar := utils.GetNewBar(progressBar, frames, filepath.Base(output), utils.Percentage)
+	err := videomanipulation.New().Merge(output, bar, chapters...)
+	if err != nil {
+		bar.Abort(false)
+		os.Remove(output)
+		return err
+	}
+	bar.SetTotal(-1, true)
+
+	durationResp, err := ffprobe.Duration(output)
+	if err != nil {
+		os.Remove(output)
+		return err
+	}
+	err = verifyMergedDuration(filepath.Base(output), durations, float64(durationResp.Streams[0].Duration))
+	if err != nil {
+		os.Remove(output)
+		return err
+	}
+
+	if keep {
+		return nil
+	}
+	for _, chapter := range chapters {
+		if err := os.Remove(chapter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+merge joins every complete recording, failures leave the chapters untouched. Chapters imported with a sidecar
+hand it over to the merged recording, their own ones go along with them unless kept.
+*/
+func (c *chapterSets) merge(options utils.ChapterOptions, progressBar *mpb.Progress, counter *utils.ResultCounter) {
+	if !options.Merge {
+		return
+	}
+	for _, chapters := range c.complete() {
+		if err := mergeChapters(chapters, options.Keep, progressBar); err != nil {
+			counter.SetFailure(err, filepath.Base(chapters[0]))
+			continue
+		}
+		sidecar, ok := c.mergedSidecar(chapters)
+		if !ok {
+			continue
+		}
+		if !options.Keep {
+			for _, chapter := range chapters {
+				if err := os.Remove(utils.SidecarPath(chapter)); err != nil && !os.IsNotExist(err) {
+					counter.SetFailure(err, filepath.Base(utils.SidecarPath(chapter)))
+				}
+			}
+		}
+		writeSidecar(MergedChapterName(chapters), sidecar, counter)
+	}
+}
@@ -0,0 +1,37 @@
+package gopro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteChapterSets(t *testing.T) {
+	chapters := chapterSets{}
+	for _, name := range []string{"GX011273.MP4", "GX021273.MP4", "GX031273.MP4", "GX011274.MP4", "GX021274.MP4", "GX011275.MP4"} {
+		chapters.see(name)
+	}
+	// GX1274 is missing its second chapter and GX1275 only has one
+	for _, path := range []string{"b/GX1273-03.MP4", "b/GX1273-01.MP4", "b/GX1273-02.MP4", "b/GX1274-01.MP4", "b/GX1275-01.MP4"} {
+		chapters.add(path)
+	}
+	require.Equal(t, [][]string{{"b/GX1273-01.MP4", "b/GX1273-02.MP4", "b/GX1273-03.MP4"}}, chapters.complete())
+
+	// the last chapter still on the camera but filtered out of the import
+	chapters.see("GX041273.MP4")
+	require.Empty(t, chapters.complete())
+
+	// a chapter with a HiLight went to another folder than the rest of its recording
+	chapters = chapterSets{}
+	for _, name := range []string{"GX011276.MP4", "GX021276.MP4"} {
+		chapters.see(name)
+	}
+	chapters.add("b/GX1276-01.MP4")
+	chapters.add("b/good stuff/GX1276-02.MP4")
+	require.Empty(t, chapters.complete())
+}
+
+func TestVerifyMergedDuration(t *testing.T) {
+	require.NoError(t, verifyMergedDuration("GX1273.MP4", []float64{531.2, 531.2, 120.5}, 1182.93))
+	require.Error(t, verifyMergedDuration("GX1273.MP4", []float64{531.2, 531.2, 120.5}, 651.7))
+}
@@ -62,3 +62,29 @@ func GetHiLights(path string) (*HiLights, error) {
 	}
 	return nil, errors.New("No data found")
 }
+
+// GetDuration reads the length in seconds of a video from its movie header, no ffprobe needed
+func GetDuration(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	boxes, err := mp4.ExtractBoxWithPayload(f, nil, mp4.BoxPath{mp4.BoxTypeMoov(), mp4.BoxTypeMvhd()})
+	if err != nil {
+		return 0, err
+	}
+	if len(boxes) == 0 {
+		return 0, errors.New("No movie header found")
+	}
+	mvhd := boxes[0].Payload.(*mp4.Mvhd)
+	if mvhd.Timescale == 0 {
+		return 0, errors.New("Invalid movie header")
+	}
+	duration := float64(mvhd.DurationV0)
+	if mvhd.GetVersion() == 1 {
+		duration = float64(mvhd.DurationV1)
+	}
+	return duration / float64(mvhd.Timescale), nil
+}
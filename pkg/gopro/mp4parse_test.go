@@ -0,0 +1,33 @@
+package gopro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abema/go-mp4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDuration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GX010001.MP4")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+
+	w := mp4.NewWriter(f)
+	_, err = w.StartBox(&mp4.BoxInfo{Type: mp4.BoxTypeMoov()})
+	require.NoError(t, err)
+	_, err = w.StartBox(&mp4.BoxInfo{Type: mp4.BoxTypeMvhd()})
+	require.NoError(t, err)
+	_, err = mp4.Marshal(w, &mp4.Mvhd{Timescale: 1000, DurationV0: 12500, Rate: 0x10000, Volume: 0x100}, mp4.Context{})
+	require.NoError(t, err)
+	_, err = w.EndBox()
+	require.NoError(t, err)
+	_, err = w.EndBox()
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	duration, err := GetDuration(path)
+	require.NoError(t, err)
+	require.Equal(t, 12.5, duration)
+}
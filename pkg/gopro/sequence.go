@@ -0,0 +1,81 @@
+package gopro
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/konradit/mmt/pkg/videomanipulation"
+	"github.com/vbauerster/mpb/v8"
+)
+
+// sequence types as reported by the media list over Connect
+var sequenceTypes = map[string]string{
+	"b": "burst",
+	"c": "continuous",
+	"t": "timelapse",
+	"n": "nightlapse",
+}
+
+// sequenceGroup is the group ID in a multishot filename, eg: G001 for G0010023.JPG or GPAA for GPAA0023.JPG
+func sequenceGroup(name string) string {
+	return name[:4]
+}
+
+// sequenceFolders collects multishot folders during an import, to be described once every frame is in
+type sequenceFolders struct {
+	mu      sync.Mutex
+	folders map[string]string
+}
+
+func (s *sequenceFolders) add(folder, kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.folders == nil {
+		s.folders = map[string]string{}
+	}
+	if s.folders[folder] == "" {
+		s.folders[folder] = kind
+	}
+}
+
+// finish writes sequence.json in every folder and renders them if asked to
+func (s *sequenceFolders) finish(options utils.SequenceOptions, progressBar *mpb.Progress, counter *utils.ResultCounter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	folders := []string{}
+	for folder := range s.folders {
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+
+	for _, folder := range folders {
+		name := filepath.Base(folder)
+		sequence, err := utils.ReadSequence(folder, name, s.folders[folder], ".JPG")
+		if err != nil {
+			counter.SetFailure(err, name)
+			continue
+		}
+		if options.Render && sequence.Frames > 1 {
+			frameRate := options.FrameRate
+			if frameRate <= 0 {
+				frameRate = 30
+			}
+			output := filepath.Join(folder, name+".MP4")
+			bar := utils.GetNewBar(progressBar, int64(sequence.Frames), filepath.Base(output), utils.Percentage)
+			err := videomanipulation.New().RenderSequence(output, frameRate, bar, sequence.Files()...)
+			if err != nil {
+				bar.Abort(false)
+				counter.SetFailure(err, filepath.Base(output))
+			} else {
+				bar.SetTotal(-1, true)
+				sequence.Render = filepath.Base(output)
+			}
+		}
+		if err := sequence.Write(folder); err != nil {
+			counter.SetFailure(err, name)
+		}
+	}
+}
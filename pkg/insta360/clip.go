@@ -0,0 +1,168 @@
+package insta360
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+)
+
+const ClipManifestFile = "clip.json"
+
+// Lens codes in Insta360 file names
+const (
+	FrontLens = "00"
+	BackLens  = "10"
+)
+
+// clipName matches VID_20221012_102725_00_586.insv: type, date, time, lens/track and sequence number
+var clipName = regexp.MustCompile(`^(?:PRO_)?(VID|LRV|IMG)_(\d{8})_(\d{6})_(\d\d)_(\d+)\.(\w+)$`)
+
+// ClipName splits an Insta360 file name into the key shared by every file of a shot, the capture time (HHMMSS) and the lens code
+func ClipName(name string) (key, id, lens string, ok bool) {
+	parts := clipName.FindStringSubmatch(name)
+	if parts == nil {
+		return "", "", "", false
+	}
+	return parts[2] + "_" + parts[3] + "_" + parts[5], parts[3], parts[4], true
+}
+
+type ClipFile struct {
+	Name string `json:"name"`
+	Lens string `json:"lens,omitempty"`
+	Size int64  `json:"size"`
+	path string
+}
+
+// Path is where the file was read from
+func (f ClipFile) Path() string {
+	return f.path
+}
+
+// Clip is every file a shot produced: one or two lens files, the LRV and the thumbnail
+type Clip struct {
+	Key          string     `json:"key"`
+	ID           string     `json:"id"`
+	Camera       string     `json:"camera,omitempty"`
+	SerialNumber string     `json:"serial_number,omitempty"`
+	Lenses       []ClipFile `json:"lenses"`
+	LRV          []ClipFile `json:"lrv,omitempty"`
+	Thumbnails   []ClipFile `json:"thumbnails,omitempty"`
+	Complete     bool       `json:"complete"`
+	Problems     []string   `json:"problems,omitempty"`
+}
+
+// Files returns the lens files first, then the LRVs and thumbnails
+func (c *Clip) Files() []ClipFile {
+	files := append([]ClipFile{}, c.Lenses...)
+	files = append(files, c.LRV...)
+	return append(files, c.Thumbnails...)
+}
+
+// DualLens is true for clips recorded as one file per lens
+func (c *Clip) DualLens() bool {
+	for _, lens := range c.Lenses {
+		if lens.Lens == BackLens {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+GroupClips groups the VID, LRV and thumbnail files of paths by shot, other files are left out.
+Clips are sorted by key, files within a clip by name.
+*/
+func GroupClips(paths []string) []*Clip {
+	clips := map[string]*Clip{}
+	for _, path := range paths {
+		name := filepath.Base(path)
+		key, id, lens, ok := ClipName(name)
+		if !ok || strings.HasPrefix(name, "IMG_") {
+			continue
+		}
+		clip, found := clips[key]
+		if !found {
+			clip = &Clip{Key: key, ID: id}
+			clips[key] = clip
+		}
+
+		file := ClipFile{Name: name, Lens: lens, path: path}
+		if info, err := os.Stat(path); err == nil {
+			file.Size = info.Size()
+		}
+		switch {
+		case strings.EqualFold(filepath.Ext(name), ".thm"):
+			clip.Thumbnails = append(clip.Thumbnails, file)
+		case strings.Contains(name, "LRV_"):
+			clip.LRV = append(clip.LRV, file)
+		default:
+			clip.Lenses = append(clip.Lenses, file)
+		}
+	}
+
+	sorted := []*Clip{}
+	for _, clip := range clips {
+		for _, files := range [][]ClipFile{clip.Lenses, clip.LRV, clip.Thumbnails} {
+			sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+		}
+		sorted = append(sorted, clip)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return sorted
+}
+
+/*
+Validate checks every lens of the clip was copied off the camera whole. With dualLens, set when the camera
+records a file per lens, a clip needs both its 00 and 10 file. A lens file without the trailer the camera writes
+when it closes the file is truncated. Complete and Problems are set for the manifest.
+*/
+func (c *Clip) Validate(dualLens bool) error {
+	c.Problems = []string{}
+	lenses := map[string]ClipFile{}
+	for _, lens := range c.Lenses {
+		lenses[lens.Lens] = lens
+	}
+
+	if len(c.Lenses) == 0 {
+		c.Problems = append(c.Problems, "no lens file, only the LRV or thumbnail")
+	}
+	if len(c.Lenses) > 0 && (dualLens || c.DualLens()) {
+		for _, code := range []string{FrontLens, BackLens} {
+			if _, found := lenses[code]; !found {
+				c.Problems = append(c.Problems, "missing lens "+code)
+			}
+		}
+	}
+	for _, lens := range c.Lenses {
+		if !strings.EqualFold(filepath.Ext(lens.Name), ".insv") {
+			continue
+		}
+		if lens.Size == 0 {
+			c.Problems = append(c.Problems, lens.Name+" is empty")
+			continue
+		}
+		if _, err := ReadTrailer(lens.path, RecordInfo); err != nil {
+			c.Problems = append(c.Problems, lens.Name+" is truncated")
+		}
+	}
+
+	c.Complete = len(c.Problems) == 0
+	if c.Complete {
+		return nil
+	}
+	return mErrors.ErrIncompleteClip(c.Key, strings.Join(c.Problems, ", "))
+}
+
+// Write stores the clip as clip.json in folder, next to the files
+func (c *Clip) Write(folder string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(folder, ClipManifestFile), b, 0o600)
+}
@@ -0,0 +1,97 @@
+package insta360
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClipName(t *testing.T) {
+	key, id, lens, ok := ClipName("VID_20221012_102725_10_586.insv")
+	require.True(t, ok)
+	require.Equal(t, "20221012_102725_586", key)
+	require.Equal(t, "102725", id)
+	require.Equal(t, BackLens, lens)
+
+	key, id, _, ok = ClipName("PRO_LRV_20221012_102725_01_586.lrv")
+	require.True(t, ok)
+	require.Equal(t, "20221012_102725_586", key)
+	require.Equal(t, "102725", id)
+
+	_, _, _, ok = ClipName("fileinfo_list.list")
+	require.False(t, ok)
+}
+
+func TestGroupClips(t *testing.T) {
+	clips := GroupClips([]string{
+		"/DCIM/Camera01/VID_20221012_102725_10_586.insv",
+		"/DCIM/Camera01/LRV_20221012_102725_01_586.lrv",
+		"/DCIM/Camera01/VID_20221012_102725_00_586.insv",
+		"/DCIM/Camera01/VID_20221012_102725_00_586.thm",
+		"/DCIM/Camera01/IMG_20221012_102800_00_587.insp",
+		"/DCIM/Camera01/VID_20221012_090000_00_580.insv",
+	})
+	require.Len(t, clips, 2)
+	require.Equal(t, "20221012_090000_580", clips[0].Key)
+
+	clip := clips[1]
+	require.Equal(t, "102725", clip.ID)
+	require.Len(t, clip.Lenses, 2)
+	require.Equal(t, FrontLens, clip.Lenses[0].Lens)
+	require.Equal(t, BackLens, clip.Lenses[1].Lens)
+	require.Len(t, clip.LRV, 1)
+	require.Len(t, clip.Thumbnails, 1)
+	require.Len(t, clip.Files(), 4)
+	require.True(t, clip.DualLens())
+	require.False(t, clips[0].DualLens())
+}
+
+func TestValidateClip(t *testing.T) {
+	info := Record{ID: RecordInfo, Data: []byte{0x12, 0x02, 'X', '3'}}
+	front := writeTrailerFile(t, "VID_20221012_102725_00_586.insv", info)
+	back := writeTrailerFile(t, "VID_20221012_102725_10_586.insv", info)
+
+	clip := GroupClips([]string{front, back})[0]
+	require.NoError(t, clip.Validate(true))
+	require.True(t, clip.Complete)
+	require.Empty(t, clip.Problems)
+
+	// a single lens clip is fine unless the camera records both lenses
+	clip = GroupClips([]string{front})[0]
+	require.NoError(t, clip.Validate(false))
+	require.Error(t, clip.Validate(true))
+	require.Equal(t, []string{"missing lens 10"}, clip.Problems)
+
+	truncated := filepath.Join(t.TempDir(), "VID_20221012_102725_10_586.insv")
+	require.NoError(t, os.WriteFile(truncated, []byte("ftyp media data without a trailer"), 0o600))
+	clip = GroupClips([]string{front, truncated})[0]
+	require.Error(t, clip.Validate(true))
+	require.False(t, clip.Complete)
+	require.Equal(t, []string{"VID_20221012_102725_10_586.insv is truncated"}, clip.Problems)
+
+	clip = GroupClips([]string{"/DCIM/Camera01/LRV_20221012_102725_01_586.lrv"})[0]
+	require.Error(t, clip.Validate(false))
+}
+
+func TestWriteClip(t *testing.T) {
+	front := writeTrailerFile(t, "VID_20221012_102725_00_586.insv", Record{ID: RecordInfo, Data: []byte{0x12, 0x02, 'X', '3'}})
+	clip := GroupClips([]string{front})[0]
+	require.NoError(t, clip.Validate(false))
+	clip.Camera = "Insta360 X3"
+
+	folder := t.TempDir()
+	require.NoError(t, clip.Write(folder))
+
+	b, err := os.ReadFile(filepath.Join(folder, ClipManifestFile))
+	require.NoError(t, err)
+	read := Clip{}
+	require.NoError(t, json.Unmarshal(b, &read))
+	require.Equal(t, "20221012_102725_586", read.Key)
+	require.Equal(t, "Insta360 X3", read.Camera)
+	require.True(t, read.Complete)
+	require.Len(t, read.Lenses, 1)
+	require.NotZero(t, read.Lenses[0].Size)
+}
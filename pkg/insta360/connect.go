@@ -0,0 +1,152 @@
+package insta360
+
+/* Insta360 Connect - import over the camera WiFi */
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/vbauerster/mpb/v8"
+)
+
+// the camera WiFi slows down when more files are downloaded at once
+const oscDownloads = 2
+
+func fileTypeOf(name string) (FileTypeMatch, bool) {
+	for _, ftype := range fileTypes {
+		if ftype.Regex.MatchString(name) {
+			return ftype, true
+		}
+	}
+	return FileTypeMatch{}, false
+}
+
+/*
+ImportConnect downloads every matching file listed by the camera to an unsorted folder, resuming files left
+over from an interrupted import, then moves them in place the same way SD card imports are sorted.
+*/
+func ImportConnect(params utils.ImportParams) (*utils.Result, error) {
+	host := params.Input
+	if host == "" {
+		host = OSCAddress
+	}
+	ctx := context.Background()
+	client := oscClient{host: host}
+	info, err := client.Info(ctx)
+	if err != nil {
+		return nil, mErrors.ErrNotFound("Insta360 camera: " + host)
+	}
+	knownCamera := params.CameraName != ""
+	if !knownCamera && info.Model != "" {
+		params.CameraName, knownCamera = Metadata{Model: info.Model}.CameraName(), true
+	}
+
+	listed, err := client.ListFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	unsorted := filepath.Join(params.Output, "unsorted")
+	if _, err := os.Stat(unsorted); os.IsNotExist(err) {
+		mkdirerr := os.MkdirAll(unsorted, 0o755)
+		if mkdirerr != nil {
+			return nil, mkdirerr
+		}
+	}
+
+	var result utils.Result
+	inlineCounter := utils.ResultCounter{}
+	progressBar := mpb.New(
+		mpb.WithWidth(60),
+		mpb.WithRefreshRate(180*time.Millisecond))
+
+	names := []string{}
+	for _, listedFile := range listed {
+		names = append(names, listedFile.Name)
+	}
+	paired := pairedPhotos(names)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	files := []importFile{}
+	sem := make(chan struct{}, oscDownloads)
+	for _, listedFile := range listed {
+		ftype, ok := fileTypeOf(listedFile.Name)
+		if !ok {
+			continue
+		}
+		if (ftype.Type == Photo || ftype.Type == RawPhoto) && !params.RawMode.Keep(ftype.Type == RawPhoto, paired[photoBase(listedFile.Name)]) {
+			continue
+		}
+		if params.SkipAuxiliaryFiles && (importFile{ftype: ftype}).auxiliary() {
+			continue
+		}
+		captured, err := listedFile.Time()
+		if err == nil && (captured.Before(params.DateRange[0]) || captured.After(params.DateRange[1])) {
+			continue
+		}
+
+		wg.Add(1)
+		bar := utils.GetNewBar(progressBar, listedFile.Size, listedFile.Name, utils.IoTX)
+		go func(listedFile OSCFile, ftype FileTypeMatch, captured time.Time, bar *mpb.Bar) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			path := filepath.Join(unsorted, listedFile.Name)
+			err := utils.ResumeDownload(path, client.MediaURL(listedFile), bar)
+			if err != nil {
+				bar.Abort(false)
+				inlineCounter.SetFailure(err, listedFile.Name)
+				return
+			}
+			bar.SetTotal(-1, true)
+			if !captured.IsZero() {
+				_ = os.Chtimes(path, captured, captured)
+			}
+			file, err := newImportFile(path, ftype, params.CameraName, knownCamera)
+			if err != nil {
+				inlineCounter.SetFailure(err, listedFile.Name)
+				return
+			}
+			if file.serial == "" {
+				file.serial = info.SerialNumber
+			}
+			mu.Lock()
+			files = append(files, file)
+			mu.Unlock()
+		}(listedFile, ftype, captured, bar)
+	}
+	wg.Wait()
+	progressBar.Shutdown()
+
+	manifests := sortFiles(files, params, &inlineCounter, func(file importFile, folder string) {
+		if _, err := os.Stat(folder); os.IsNotExist(err) {
+			mkdirerr := os.MkdirAll(folder, 0o755)
+			if mkdirerr != nil {
+				log.Fatal(mkdirerr.Error())
+			}
+		}
+		if err := os.Rename(file.path, filepath.Join(folder, file.name)); err != nil {
+			inlineCounter.SetFailure(err, file.name)
+			return
+		}
+		inlineCounter.SetSuccess()
+		writeSidecar(file, filepath.Join(folder, file.name), params.Sidecars, &inlineCounter)
+	})
+	writeManifests(manifests, &inlineCounter)
+
+	result.Errors = append(result.Errors, inlineCounter.Get().Errors...)
+	result.FilesImported += inlineCounter.Get().FilesImported
+	result.FilesNotImported = append(result.FilesNotImported, inlineCounter.Get().FilesNotImported...)
+
+	// cleanup, left in place when something could not be moved
+	os.Remove(unsorted)
+	return &result, nil
+}
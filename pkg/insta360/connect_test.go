@@ -0,0 +1,83 @@
+package insta360
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListFiles(t *testing.T) {
+	camera := newFakeOSC(t)
+	captured := time.Date(2022, 10, 12, 10, 27, 25, 0, time.FixedZone("", 2*60*60))
+	for i := 0; i < oscPageSize+3; i++ {
+		camera.add(fmt.Sprintf("IMG_%03d.jpg", i), []byte("photo"), captured)
+	}
+
+	ctx := context.Background()
+	client := oscClient{host: camera.host()}
+	info, err := client.Info(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "Insta360 X3", info.Model)
+
+	files, err := client.ListFiles(ctx)
+	require.NoError(t, err)
+	require.Len(t, files, oscPageSize+3)
+	when, err := files[0].Time()
+	require.NoError(t, err)
+	require.True(t, captured.Equal(when))
+
+	require.Error(t, client.execute(ctx, "camera.takePicture", nil, &struct{}{}))
+}
+
+func TestImportConnect(t *testing.T) {
+	captured := time.Date(2022, 10, 12, 12, 27, 25, 0, time.UTC)
+	info := Record{ID: RecordInfo, Data: append(protoString(infoModel, "Insta360 X3"), protoVarint(infoCaptureTime, uint64(captured.UnixMilli()))...)}
+
+	camera := newFakeOSC(t)
+	front := writeTrailerFile(t, "VID_20221012_122725_00_586.insv", info)
+	camera.addFile(t, front, captured)
+	camera.addFile(t, writeTrailerFile(t, "VID_20221012_122725_10_586.insv", info), captured)
+	camera.addFile(t, writeTrailerFile(t, "LRV_20221012_122725_01_586.insv", info), captured)
+	camera.add("IMG_20221012_122800_00_587.insp", []byte("photo"), captured.Add(35*time.Second))
+	camera.add("IMG_20200101_120000_00_001.insp", []byte("old photo"), time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	camera.add("fileinfo_list.list", []byte("manifest"), captured)
+
+	output := t.TempDir()
+	params := utils.ImportParams{
+		Input:      camera.host(),
+		Output:     output,
+		DateFormat: "dd-mm-yyyy",
+		DateRange:  []time.Time{time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), time.Now()},
+		Connection: utils.Connect,
+		Sort:       utils.SortOptions{ByCamera: true},
+	}
+
+	// a download cut short by a previous import is resumed
+	content, err := os.ReadFile(front)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(output, "unsorted"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(output, "unsorted", filepath.Base(front)+".tmp"), content[:10], 0o600))
+
+	result, err := Entrypoint{}.Import(params)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	require.Equal(t, 4, result.FilesImported)
+	require.Equal(t, "bytes=10-", camera.ranges[filepath.Base(front)])
+
+	day := filepath.Join(output, captured.Local().Format("02-01-2006"), "Insta360 X3")
+	clipFolder := filepath.Join(day, "videos", "360", "122725")
+	for _, name := range []string{"VID_20221012_122725_00_586.insv", "VID_20221012_122725_10_586.insv", "LRV_20221012_122725_01_586.insv", ClipManifestFile} {
+		require.FileExists(t, filepath.Join(clipFolder, name))
+	}
+	imported, err := os.ReadFile(filepath.Join(clipFolder, "VID_20221012_122725_00_586.insv"))
+	require.NoError(t, err)
+	require.Equal(t, content, imported)
+	require.FileExists(t, filepath.Join(day, "photos", "122800", "IMG_20221012_122800_00_587.insp"))
+	require.NoDirExists(t, filepath.Join(output, "unsorted"))
+}
@@ -1,6 +1,12 @@
 package insta360
 
 import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"time"
+
 	mErrors "github.com/konradit/mmt/pkg/errors"
 	"github.com/konradit/mmt/pkg/utils"
 	"github.com/shirou/gopsutil/disk"
@@ -16,5 +22,47 @@ func Detect() (string, utils.ConnectionType, error) {
 			return partition.Device, utils.SDCard, nil
 		}
 	}
+	// no card mounted, try a camera on WiFi
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := (oscClient{host: OSCAddress}).Info(ctx); err == nil {
+		return OSCAddress, utils.Connect, nil
+	}
 	return "", "", mErrors.ErrNoCameraDetected
 }
+
+var errModelFound = errors.New("model found")
+
+/*
+DetectModel reads the model of the camera that wrote the card from the DCIM manifest. Cameras without
+a manifest, like the Ace Pro and GO 3, are identified by the trailer of the first media file.
+*/
+func DetectModel(sdcard string) (Camera, error) {
+	root := filepath.Join(sdcard, "DCIM")
+	if name, found := getDeviceName(filepath.Join(root, "fileinfo_list.list")); found {
+		return CameraGet(name)
+	}
+
+	name := ""
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if _, _, _, ok := ClipName(d.Name()); !ok {
+			return nil
+		}
+		metadata, err := ReadMetadata(path, RecordInfo)
+		if err != nil || metadata.Model == "" {
+			return nil
+		}
+		name = metadata.Model
+		return errModelFound
+	})
+	if err != nil && !errors.Is(err, errModelFound) {
+		return OneX, err
+	}
+	if name == "" {
+		return OneX, mErrors.ErrNoCameraDetected
+	}
+	return CameraGet(name)
+}
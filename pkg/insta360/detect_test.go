@@ -0,0 +1,60 @@
+package insta360
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCameraGet(t *testing.T) {
+	for name, camera := range map[string]Camera{
+		"insta360-onex2":   OneX2,
+		"Insta360 ONE X2":  OneX2,
+		"Insta360 X4":      X4,
+		"Insta360 GO 3":    Go3,
+		"insta360-ace-pro": AcePro,
+		"Insta360 Ace Pro": AcePro,
+		"Ace":              Ace,
+	} {
+		got, err := CameraGet(name)
+		require.NoError(t, err, name)
+		require.Equal(t, camera, got, name)
+	}
+
+	_, err := CameraGet("insta360-")
+	require.Error(t, err)
+	_, err = CameraGet("Insta360 Nano")
+	require.Error(t, err)
+}
+
+func TestDetectModel(t *testing.T) {
+	t.Run("Manifest", func(t *testing.T) {
+		card := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(card, "DCIM"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(card, "DCIM", "fileinfo_list.list"), protoString(infoModel, "Insta360 X3"), 0o600))
+
+		camera, err := DetectModel(card)
+		require.NoError(t, err)
+		require.Equal(t, X3, camera)
+	})
+	t.Run("Trailer", func(t *testing.T) {
+		card := t.TempDir()
+		folder := filepath.Join(card, "DCIM", "Camera01")
+		require.NoError(t, os.MkdirAll(folder, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(folder, "VID_20231112_101500_00_001.thm"), []byte("thumbnail"), 0o600))
+		video := writeTrailerFile(t, "VID_20231112_101500_00_001.mp4", Record{ID: RecordInfo, Data: protoString(infoModel, "Insta360 Ace Pro")})
+		require.NoError(t, os.Rename(video, filepath.Join(folder, filepath.Base(video))))
+
+		camera, err := DetectModel(card)
+		require.NoError(t, err)
+		require.Equal(t, AcePro, camera)
+	})
+	t.Run("Empty card", func(t *testing.T) {
+		card := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(card, "DCIM"), 0o755))
+		_, err := DetectModel(card)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,122 @@
+package insta360
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOSC serves enough of the Open Spherical Camera API to import from
+type fakeOSC struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	files    map[string][]byte
+	captured map[string]time.Time
+	ranges   map[string]string
+}
+
+func newFakeOSC(t *testing.T) *fakeOSC {
+	t.Helper()
+	camera := &fakeOSC{files: map[string][]byte{}, captured: map[string]time.Time{}, ranges: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/osc/info", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(OSCInfo{
+			Manufacturer:    "Arashi Vision",
+			Model:           "Insta360 X3",
+			SerialNumber:    "IXSE42A0ABCDEF",
+			FirmwareVersion: "v1.0.04",
+		})
+	})
+	mux.HandleFunc("/osc/commands/execute", camera.execute)
+	mux.HandleFunc("/DCIM/Camera01/", camera.download)
+	camera.Server = httptest.NewServer(mux)
+	t.Cleanup(camera.Close)
+	return camera
+}
+
+func (c *fakeOSC) host() string {
+	return strings.TrimPrefix(c.URL, "http://")
+}
+
+func (c *fakeOSC) add(name string, content []byte, captured time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files[name] = content
+	c.captured[name] = captured
+}
+
+// addFile serves a file written by writeTrailerFile
+func (c *fakeOSC) addFile(t *testing.T, path string, captured time.Time) {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	c.add(path[strings.LastIndex(path, string(os.PathSeparator))+1:], content, captured)
+}
+
+func (c *fakeOSC) execute(w http.ResponseWriter, r *http.Request) {
+	command := struct {
+		Name       string `json:"name"`
+		Parameters struct {
+			StartPosition int `json:"startPosition"`
+			EntryCount    int `json:"entryCount"`
+		} `json:"parameters"`
+	}{}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&command) != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if command.Name != "camera.listFiles" {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"name":  command.Name,
+			"state": "error",
+			"error": map[string]string{"code": "unknownCommand", "message": command.Name},
+		})
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := []string{}
+	for name := range c.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entries := []OSCFile{}
+	for i := command.Parameters.StartPosition; i < len(names) && len(entries) < command.Parameters.EntryCount; i++ {
+		entries = append(entries, OSCFile{
+			Name:         names[i],
+			FileURL:      c.URL + "/DCIM/Camera01/" + names[i],
+			Size:         int64(len(c.files[names[i]])),
+			DateTimeZone: c.captured[names[i]].Format("2006:01:02 15:04:05-07:00"),
+		})
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":    command.Name,
+		"state":   "done",
+		"results": map[string]interface{}{"entries": entries, "totalEntries": len(names)},
+	})
+}
+
+func (c *fakeOSC) download(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	name := strings.TrimPrefix(r.URL.Path, "/DCIM/Camera01/")
+	content, ok := c.files[name]
+	if r.Header.Get("Range") != "" {
+		c.ranges[name] = r.Header.Get("Range")
+	}
+	c.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+}
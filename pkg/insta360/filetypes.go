@@ -66,6 +66,13 @@ var fileTypes = []FileTypeMatch{
 		OSCMode:       false,
 		ProMode:       false,
 	},
+	{
+		Regex:         regexp.MustCompile(`^LRV_\d+_\d+_\d\d_\d+\.lrv$`),
+		Type:          LowResolutionVideo,
+		SteadyCamMode: false,
+		OSCMode:       false,
+		ProMode:       false,
+	},
 	{
 		Regex:         regexp.MustCompile(`^PRO_LRV_\d+_\d+_\d\d_\d+\.lrv$`),
 		Type:          LowResolutionVideo,
@@ -73,4 +80,11 @@ var fileTypes = []FileTypeMatch{
 		OSCMode:       false,
 		ProMode:       true,
 	},
+	{
+		Regex:         regexp.MustCompile(`^(PRO_)?VID_\d+_\d+_\d\d_\d+\.thm$`),
+		Type:          Thumbnail,
+		SteadyCamMode: false,
+		OSCMode:       false,
+		ProMode:       false,
+	},
 }
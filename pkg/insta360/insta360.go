@@ -2,12 +2,9 @@ package insta360
 
 import (
 	"bytes"
-	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -21,31 +18,248 @@ import (
 	"gopkg.in/djherbis/times.v1"
 )
 
-func getDeviceName(manifest string) string {
+// getDeviceName finds the model in the card manifest, a protobuf with the model as field 2, eg: 0x12 0x0B "Insta360 X3"
+func getDeviceName(manifest string) (string, bool) {
 	name := "Insta360 Camera"
 	file, err := os.ReadFile(manifest)
 	if err != nil {
-		return name
+		return name, false
 	}
 
-	endBytes := []byte{0x1A, 0x0F}
+	marker := []byte("Insta360")
+	for offset := 0; ; {
+		i := bytes.Index(file[offset:], marker)
+		if i < 0 {
+			return name, false
+		}
+		i += offset
+		if i >= 2 && file[i-2] == 0x12 && i+int(file[i-1]) <= len(file) {
+			return string(file[i : i+int(file[i-1])]), true
+		}
+		offset = i + len(marker)
+	}
+}
+
+var locationService = LocationService{}
 
-	res := bytes.Split(file, append([]byte{0x12, 0x0B}, []byte("Insta360")...))
-	if len(res) == 1 {
-		return name
+type Entrypoint struct{}
+
+// importFile is a media file found on the card, waiting to be copied with the rest of its clip
+type importFile struct {
+	path, name string
+	ftype      FileTypeMatch
+	date       time.Time
+	camera     string
+	serial     string
+	size       int64
+	alongside  bool // a raw+JPEG sibling is imported to the same folder
+}
+
+func (f importFile) mediaDate(dateFormat string) string {
+	if strings.Contains(dateFormat, "yyyy") && strings.Contains(dateFormat, "mm") && strings.Contains(dateFormat, "dd") {
+		return f.date.Format(utils.DateFormatReplacer.Replace(dateFormat))
 	}
-	modelName := bytes.Split(res[1], endBytes)
-	if len(modelName) == 1 {
-		return name
+	return f.date.Format("02-01-2006")
+}
+
+// flat is true for flat video: .mp4 clips, and every clip from a single lens camera like the GO 3 or Ace Pro
+func (f importFile) flat() bool {
+	if f.ftype.SteadyCamMode {
+		return true
 	}
-	return fmt.Sprintf("Insta360%s", modelName[0])
+	camera, err := CameraGet(f.camera)
+	return err == nil && camera.SingleLens()
 }
 
-type Entrypoint struct{}
+// slug is the folder a file goes to under the day folder
+func (f importFile) slug() string {
+	switch {
+	case f.ftype.Type == Photo || f.ftype.Type == RawPhoto:
+		return "photos"
+	case f.ftype.SteadyCamMode && f.ftype.ProMode:
+		return "videos/flat/pro_mode"
+	case f.flat():
+		return "videos/flat"
+	}
+	return "videos/360"
+}
+
+func (f importFile) auxiliary() bool {
+	return f.ftype.Type == LowResolutionVideo || f.ftype.Type == Thumbnail
+}
+
+// newImportFile reads the capture time, camera and serial number from the trailer, the file time is the fallback
+func newImportFile(path string, ftype FileTypeMatch, cameraName string, knownCamera bool) (importFile, error) {
+	t, err := times.Stat(path)
+	if err != nil {
+		return importFile{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return importFile{}, err
+	}
+	file := importFile{path: path, name: filepath.Base(path), ftype: ftype, date: t.ModTime(), camera: cameraName, size: info.Size()}
+	if metadata, err := ReadMetadata(path, RecordInfo); err == nil {
+		if !metadata.CaptureTime.IsZero() {
+			file.date = metadata.CaptureTime
+		}
+		if !knownCamera && metadata.CameraName() != "" {
+			file.camera = metadata.CameraName()
+		}
+		file.serial = metadata.SerialNumber
+	}
+	return file, nil
+}
+
+/*
+findFiles lists the media under DCIM taken within the date range. Every folder is walked as cameras
+move on to Camera02 and up once Camera01 is full, hidden folders are skipped.
+*/
+func findFiles(root string, params utils.ImportParams, knownCamera bool) ([]importFile, error) {
+	files := []importFile{}
+	err := godirwalk.Walk(root, &godirwalk.Options{
+		Unsorted: true,
+		Callback: func(osPathname string, de *godirwalk.Dirent) error {
+			if de.IsDir() && osPathname != root && strings.HasPrefix(de.Name(), ".") {
+				return godirwalk.SkipThis
+			}
+			for _, ftype := range fileTypes {
+				if !ftype.Regex.MatchString(de.Name()) {
+					continue
+				}
+				file, err := newImportFile(osPathname, ftype, params.CameraName, knownCamera)
+				if err != nil {
+					return godirwalk.SkipThis
+				}
+
+				// check if is in date range
+				if file.date.Before(params.DateRange[0]) || file.date.After(params.DateRange[1]) {
+					return godirwalk.SkipThis
+				}
+				if params.SkipAuxiliaryFiles && file.auxiliary() {
+					return nil
+				}
+				files = append(files, file)
+				return nil
+			}
+			return nil
+		},
+	})
+	return files, err
+}
+
+/*
+sortFiles hands every file to transfer along with its folder: photos by capture time, both lenses, the LRV and
+the thumbnail of a clip together. The manifest of each clip is returned by folder, to be written once transferred.
+*/
+func sortFiles(files []importFile, params utils.ImportParams, counter *utils.ResultCounter, transfer func(file importFile, folder string)) map[string]*Clip {
+	byPath := map[string]importFile{}
+	videos := []string{}
+	dualLens := false
+	names := []string{}
+	for _, file := range files {
+		names = append(names, file.name)
+	}
+	paired := pairedPhotos(names)
+	for _, file := range files {
+		_, id, lens, ok := ClipName(file.name)
+		if !ok {
+			continue
+		}
+		if file.ftype.Type == Photo || file.ftype.Type == RawPhoto {
+			if !params.RawMode.Keep(file.ftype.Type == RawPhoto, paired[photoBase(file.name)]) {
+				continue
+			}
+			// photos of a clip share its folder, the sibling lands next to it whenever it is kept too
+			file.alongside = paired[photoBase(file.name)] && params.RawMode.Keep(file.ftype.Type != RawPhoto, true)
+			dayFolder := utils.GetOrder(params.Sort, locationService, file.path, params.Output, file.mediaDate(params.DateFormat), file.camera)
+			transfer(file, filepath.Join(dayFolder, file.slug(), id))
+			continue
+		}
+		byPath[file.path] = file
+		videos = append(videos, file.path)
+		dualLens = dualLens || lens == BackLens
+	}
+
+	manifests := map[string]*Clip{}
+	for _, clip := range GroupClips(videos) {
+		clipFiles := clip.Files()
+		first := byPath[clipFiles[0].Path()]
+		// flat .mp4 clips are a single file even on dual lens cameras
+		if err := clip.Validate(dualLens && !first.flat()); err != nil {
+			if params.SkipIncomplete {
+				for _, clipFile := range clipFiles {
+					counter.SetFailure(err, clipFile.Name)
+				}
+				continue
+			}
+			color.Yellow(">> %s", err.Error())
+		}
+		clip.Camera = first.camera
+		clip.SerialNumber = first.serial
+
+		dayFolder := utils.GetOrder(params.Sort, locationService, first.path, params.Output, first.mediaDate(params.DateFormat), first.camera)
+		folder := filepath.Join(dayFolder, first.slug(), clip.ID)
+		for _, clipFile := range clipFiles {
+			transfer(byPath[clipFile.Path()], folder)
+		}
+		manifests[folder] = clip
+	}
+
+	return manifests
+}
+
+func photoBase(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+}
+
+// pairedPhotos lists the photos shot as raw+JPEG among names, by photoBase
+func pairedPhotos(names []string) map[string]bool {
+	raws, jpegs := map[string]bool{}, map[string]bool{}
+	for _, name := range names {
+		if utils.IsRaw(name) {
+			raws[photoBase(name)] = true
+		} else {
+			jpegs[photoBase(name)] = true
+		}
+	}
+	paired := map[string]bool{}
+	for base := range raws {
+		paired[base] = jpegs[base]
+	}
+	return paired
+}
+
+// writeSidecar describes an imported file with an XMP sidecar next to it, raws always get one
+func writeSidecar(file importFile, path string, sidecars bool, counter *utils.ResultCounter) {
+	if file.ftype.Type != RawPhoto && (!sidecars || file.auxiliary()) {
+		return
+	}
+	if !utils.WritesSidecar(file.ftype.Type == RawPhoto, file.alongside) {
+		return
+	}
+	sidecar := utils.XMP{Make: "Insta360", Model: file.camera, SerialNumber: file.serial, CreateDate: file.date}
+	if err := utils.WriteSidecar(locationService, path, sidecar); err != nil {
+		counter.SetFailure(err, utils.SidecarPath(file.name))
+	}
+}
+
+// writeManifests stores clip.json next to the files of each clip
+func writeManifests(manifests map[string]*Clip, counter *utils.ResultCounter) {
+	for folder, clip := range manifests {
+		if err := clip.Write(folder); err != nil {
+			counter.SetFailure(err, ClipManifestFile)
+		}
+	}
+}
 
 func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
-	if params.CameraName == "" {
-		params.CameraName = getDeviceName(filepath.Join(params.Input, "DCIM", "fileinfo_list.list"))
+	if params.Connection == utils.Connect {
+		return ImportConnect(params)
+	}
+	knownCamera := params.CameraName != ""
+	if !knownCamera {
+		params.CameraName, knownCamera = getDeviceName(filepath.Join(params.Input, "DCIM", "fileinfo_list.list"))
 	}
 	di, err := disk.GetInfo(params.Input)
 	if err != nil {
@@ -59,16 +273,12 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 		percentage,
 	)
 
-	mediaFolder := `Camera\d+`
-	mediaFolderRegex := regexp.MustCompile(mediaFolder)
-
-	root := filepath.Join(params.Input, "DCIM")
 	var result utils.Result
+	inlineCounter := utils.ResultCounter{}
 
-	folders, err := ioutil.ReadDir(root)
+	files, err := findFiles(filepath.Join(params.Input, "DCIM"), params, knownCamera)
 	if err != nil {
-		result.Errors = append(result.Errors, err)
-		return &result, nil
+		inlineCounter.SetFailure(err, "")
 	}
 
 	var wg sync.WaitGroup
@@ -76,123 +286,36 @@ func (Entrypoint) Import(params utils.ImportParams) (*utils.Result, error) {
 		mpb.WithWidth(60),
 		mpb.WithRefreshRate(180*time.Millisecond))
 
-	inlineCounter := utils.ResultCounter{}
-
-	for _, f := range folders {
-		r := mediaFolderRegex.MatchString(f.Name())
-		if !r {
-			continue
+	copyTo := func(file importFile, folder string) {
+		if _, err := os.Stat(folder); os.IsNotExist(err) {
+			mkdirerr := os.MkdirAll(folder, 0o755)
+			if mkdirerr != nil {
+				log.Fatal(mkdirerr.Error())
+			}
 		}
+		wg.Add(1)
+		bar := utils.GetNewBar(progressBar, file.size, file.name, utils.IoTX)
+		go func(file importFile, bar *mpb.Bar) {
+			defer wg.Done()
 
-		err = godirwalk.Walk(filepath.Join(root, f.Name()), &godirwalk.Options{
-			Unsorted: true,
-			Callback: func(osPathname string, de *godirwalk.Dirent) error {
-				for _, ftype := range fileTypes {
-					if !ftype.Regex.MatchString(de.Name()) {
-						continue
-					}
-					t, err := times.Stat(osPathname)
-					if err != nil {
-						return godirwalk.SkipThis
-					}
-
-					d := t.ModTime()
-
-					mediaDate := d.Format("02-01-2006")
-					if strings.Contains(params.DateFormat, "yyyy") && strings.Contains(params.DateFormat, "mm") && strings.Contains(params.DateFormat, "dd") {
-						mediaDate = d.Format(utils.DateFormatReplacer.Replace(params.DateFormat))
-					}
-
-					// check if is in date range
-
-					if d.Before(params.DateRange[0]) || d.After(params.DateRange[1]) {
-						return godirwalk.SkipThis
-					}
-
-					info, err := os.Stat(osPathname)
-					if err != nil {
-						return godirwalk.SkipThis
-					}
-
-					wg.Add(1)
-					bar := utils.GetNewBar(progressBar, info.Size(), de.Name(), utils.IoTX)
-					dayFolder := utils.GetOrder(params.Sort, nil, osPathname, params.Output, mediaDate, params.CameraName)
-
-					x := de.Name()
-
-					switch ftype.Type {
-					case Photo, RawPhoto:
-						id := x[3+8+2 : 3+8+6+2]
-						if _, err := os.Stat(filepath.Join(dayFolder, "photos", id)); os.IsNotExist(err) {
-							mkdirerr := os.MkdirAll(filepath.Join(dayFolder, "photos", id), 0o755)
-							if mkdirerr != nil {
-								log.Fatal(mkdirerr.Error())
-							}
-						}
-
-						go func(id, filename, osPathname string, bar *mpb.Bar) {
-							defer wg.Done()
-
-							err = utils.CopyFile(osPathname, filepath.Join(dayFolder, "photos", id, x), params.BufferSize, bar, d)
-							if err != nil {
-								bar.EwmaSetCurrent(info.Size(), 1*time.Millisecond)
-								bar.EwmaIncrInt64(info.Size(), 1*time.Millisecond)
-								inlineCounter.SetFailure(err, filename)
-							} else {
-								inlineCounter.SetSuccess()
-							}
-						}(id, x, osPathname, bar)
-					case Video, LowResolutionVideo:
-						if params.SkipAuxiliaryFiles && ftype.Type == LowResolutionVideo {
-							wg.Done()
-							bar.Abort(true)
-							break
-						}
-						slug := ""
-						if ftype.SteadyCamMode {
-							slug = "videos/flat"
-							if ftype.ProMode {
-								slug = "videos/flat/pro_mode"
-							}
-						} else {
-							slug = "videos/360"
-						}
-						id := x[3+8+2 : 3+8+6+2]
-						if ftype.ProMode {
-							id = x[3+3+8+2+1 : 3+3+8+6+2+1]
-						}
-						if _, err := os.Stat(filepath.Join(dayFolder, slug, id)); os.IsNotExist(err) {
-							mkdirerr := os.MkdirAll(filepath.Join(dayFolder, slug, id), 0o755)
-							if mkdirerr != nil {
-								log.Fatal(mkdirerr.Error())
-							}
-						}
-
-						go func(id, filename, osPathname string, bar *mpb.Bar) {
-							defer wg.Done()
-
-							err = utils.CopyFile(osPathname, filepath.Join(dayFolder, slug, id, x), params.BufferSize, bar, d)
-							if err != nil {
-								bar.EwmaSetCurrent(info.Size(), 1*time.Millisecond)
-								bar.EwmaIncrInt64(info.Size(), 1*time.Millisecond)
-								inlineCounter.SetFailure(err, filename)
-							} else {
-								inlineCounter.SetSuccess()
-							}
-						}(id, x, osPathname, bar)
-					}
-				}
-				return nil
-			},
-		})
-
-		if err != nil {
-			inlineCounter.SetFailure(err, "")
-		}
+			err := utils.CopyFile(file.path, filepath.Join(folder, file.name), params.BufferSize, bar, file.date)
+			if err != nil {
+				bar.EwmaSetCurrent(file.size, 1*time.Millisecond)
+				bar.EwmaIncrInt64(file.size, 1*time.Millisecond)
+				inlineCounter.SetFailure(err, file.name)
+			} else {
+				inlineCounter.SetSuccess()
+				writeSidecar(file, filepath.Join(folder, file.name), params.Sidecars, &inlineCounter)
+			}
+		}(file, bar)
 	}
+
+	manifests := sortFiles(files, params, &inlineCounter, copyTo)
 	wg.Wait()
 	progressBar.Shutdown()
 
+	writeManifests(manifests, &inlineCounter)
+
 	result.Errors = append(result.Errors, inlineCounter.Get().Errors...)
 	result.FilesImported += inlineCounter.Get().FilesImported
 	result.FilesNotImported = append(result.FilesNotImported, inlineCounter.Get().FilesNotImported...)
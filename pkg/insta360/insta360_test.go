@@ -0,0 +1,98 @@
+package insta360
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCard puts files on a card under DCIM/Camera01, lens files carry a trailer naming the model
+func writeCard(t *testing.T, model string, names ...string) string {
+	card := t.TempDir()
+	folder := filepath.Join(card, "DCIM", "Camera01")
+	require.NoError(t, os.MkdirAll(folder, 0o755))
+	written := time.Date(2024, 4, 20, 12, 0, 0, 0, time.Local)
+	for _, name := range names {
+		path := filepath.Join(folder, name)
+		switch filepath.Ext(name) {
+		case ".insv", ".mp4", ".insp":
+			require.NoError(t, os.Rename(writeTrailerFile(t, name, Record{ID: RecordInfo, Data: protoString(infoModel, model)}), path))
+		default:
+			require.NoError(t, os.WriteFile(path, []byte(name), 0o600))
+		}
+		require.NoError(t, os.Chtimes(path, written, written))
+	}
+	return card
+}
+
+func importedFiles(t *testing.T, output string) []string {
+	files := []string{}
+	require.NoError(t, filepath.WalkDir(output, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(output, path)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	}))
+	sort.Strings(files)
+	return files
+}
+
+func TestImport(t *testing.T) {
+	for model, test := range map[string]struct {
+		names    []string
+		expected []string
+	}{
+		"Insta360 X4": {
+			names: []string{"VID_20240420_101010_00_001.insv", "LRV_20240420_101010_01_001.lrv", "IMG_20240420_102000_00_002.insp"},
+			expected: []string{
+				"20-04-2024/photos/102000/IMG_20240420_102000_00_002.insp",
+				"20-04-2024/videos/360/101010/LRV_20240420_101010_01_001.lrv",
+				"20-04-2024/videos/360/101010/VID_20240420_101010_00_001.insv",
+				"20-04-2024/videos/360/101010/clip.json",
+			},
+		},
+		"Insta360 Ace Pro": {
+			names: []string{"VID_20240420_120111_00_011.mp4", "LRV_20240420_120111_11_011.lrv", "IMG_20240420_121500_00_012.jpg", "IMG_20240420_121500_00_012.dng"},
+			expected: []string{
+				"20-04-2024/photos/121500/IMG_20240420_121500_00_012.dng",
+				"20-04-2024/photos/121500/IMG_20240420_121500_00_012.jpg",
+				"20-04-2024/photos/121500/IMG_20240420_121500_00_012.xmp",
+				"20-04-2024/videos/flat/120111/LRV_20240420_120111_11_011.lrv",
+				"20-04-2024/videos/flat/120111/VID_20240420_120111_00_011.mp4",
+				"20-04-2024/videos/flat/120111/clip.json",
+			},
+		},
+		// the GO 3 records flat .insv clips for FlowState, and .mp4 in Pro Video
+		"Insta360 GO 3": {
+			names: []string{"VID_20240420_102030_00_001.insv", "LRV_20240420_102030_11_001.lrv", "PRO_VID_20240420_110000_00_002.mp4"},
+			expected: []string{
+				"20-04-2024/videos/flat/102030/LRV_20240420_102030_11_001.lrv",
+				"20-04-2024/videos/flat/102030/VID_20240420_102030_00_001.insv",
+				"20-04-2024/videos/flat/102030/clip.json",
+				"20-04-2024/videos/flat/pro_mode/110000/PRO_VID_20240420_110000_00_002.mp4",
+				"20-04-2024/videos/flat/pro_mode/110000/clip.json",
+			},
+		},
+	} {
+		output := t.TempDir()
+		result, err := Entrypoint{}.Import(utils.ImportParams{
+			Input:      writeCard(t, model, test.names...),
+			Output:     output,
+			DateFormat: "dd-mm-yyyy",
+			DateRange:  []time.Time{time.Date(2024, 4, 20, 0, 0, 0, 0, time.Local), time.Date(2024, 4, 20, 23, 59, 59, 0, time.Local)},
+			BufferSize: 1000,
+			RawMode:    utils.RawPair,
+		})
+		require.NoError(t, err, model)
+		require.Empty(t, result.Errors, model)
+		require.Equal(t, len(test.names), result.FilesImported, model)
+		require.Equal(t, test.expected, importedFiles(t, output), model)
+	}
+}
@@ -0,0 +1,42 @@
+package insta360
+
+import (
+	"path/filepath"
+	"strings"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+	"github.com/konradit/mmt/pkg/utils"
+)
+
+type LocationService struct{}
+
+func (LocationService) GetLocation(path string) (*utils.Location, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".insv", ".mp4", ".lrv":
+		return fromTrailer(path)
+	case ".insp":
+		location, err := fromTrailer(path)
+		if err != nil {
+			return utils.LocationFromEXIF(path)
+		}
+		return location, nil
+	case ".jpg", ".dng":
+		return utils.LocationFromEXIF(path)
+	default:
+		return nil, mErrors.ErrInvalidFile
+	}
+}
+
+// fromTrailer returns the first fix the app or GPS remote wrote to the trailer
+func fromTrailer(path string) (*utils.Location, error) {
+	records, err := ReadTrailer(path, RecordGPS)
+	if err != nil {
+		return nil, err
+	}
+	for _, gps := range GPS(records) {
+		if gps.Valid && (gps.Latitude != 0 || gps.Longitude != 0) {
+			return &utils.Location{Latitude: gps.Latitude, Longitude: gps.Longitude}, nil
+		}
+	}
+	return nil, mErrors.ErrNoGPS
+}
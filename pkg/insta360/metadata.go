@@ -0,0 +1,176 @@
+package insta360
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"time"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+)
+
+// Metadata is what the camera writes to the trailer of every .insv, .insp and .mp4
+type Metadata struct {
+	Model        string
+	SerialNumber string
+	Firmware     string
+	CaptureTime  time.Time
+	LensOffset   string // lens count, then per lens centre, radius and orientation, then the sensor size
+	Gyro         []GyroRecord
+	Exposure     []ExposureRecord
+	GPS          []GPSRecord
+}
+
+// GyroRecord is a 56 byte 0x300 entry: clip time in ms (uint64), accelerometer in g and gyroscope in rad/s (float64)
+type GyroRecord struct {
+	Time          time.Duration
+	Accelerometer [3]float64
+	Gyroscope     [3]float64
+}
+
+// ExposureRecord is a 16 byte 0x400 entry: clip time in ms (uint64) and shutter speed in seconds (float64)
+type ExposureRecord struct {
+	Time     time.Duration
+	Exposure float64
+}
+
+// Fields of the 0x101 info protobuf
+const (
+	infoSerialNumber = 1
+	infoModel        = 2
+	infoFirmware     = 3
+	infoLensOffset   = 5
+	infoCaptureTime  = 7 // ms since epoch
+)
+
+const (
+	gyroRecordSize     = 56
+	exposureRecordSize = 16
+)
+
+// CameraName is the model prefixed like the manifest names cameras, eg: Insta360 X3
+func (m Metadata) CameraName() string {
+	if m.Model == "" {
+		return ""
+	}
+	if strings.HasPrefix(m.Model, "Insta360") {
+		return m.Model
+	}
+	return "Insta360 " + m.Model
+}
+
+// ReadMetadata parses the trailer of path, limited to the records in ids when given
+func ReadMetadata(path string, ids ...uint16) (*Metadata, error) {
+	records, err := ReadTrailer(path, ids...)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &Metadata{}
+	for _, record := range records {
+		switch record.ID {
+		case RecordInfo:
+			if err := metadata.parseInfo(record.Data); err != nil {
+				return nil, err
+			}
+		case RecordGyro:
+			metadata.Gyro = append(metadata.Gyro, parseGyro(record.Data)...)
+		case RecordExposure:
+			metadata.Exposure = append(metadata.Exposure, parseExposure(record.Data)...)
+		case RecordGPS:
+			metadata.GPS = append(metadata.GPS, parseGPS(record.Data)...)
+		}
+	}
+	return metadata, nil
+}
+
+func (m *Metadata) parseInfo(data []byte) error {
+	return readProtobuf(data, func(field int, varint uint64, bytes []byte) {
+		switch field {
+		case infoSerialNumber:
+			m.SerialNumber = string(bytes)
+		case infoModel:
+			m.Model = string(bytes)
+		case infoFirmware:
+			m.Firmware = string(bytes)
+		case infoLensOffset:
+			m.LensOffset = string(bytes)
+		case infoCaptureTime:
+			if varint > 0 {
+				m.CaptureTime = time.UnixMilli(int64(varint))
+			}
+		}
+	})
+}
+
+/*
+readProtobuf calls field for every top level field of a protobuf message, with the value of
+varints or the content of length delimited fields. Fixed size fields are skipped.
+*/
+func readProtobuf(data []byte, field func(number int, varint uint64, bytes []byte)) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return mErrors.ErrInvalidSuppliedData("protobuf key")
+		}
+		data = data[n:]
+		number := int(key >> 3)
+
+		switch key & 7 {
+		case 0:
+			value, n := binary.Uvarint(data)
+			if n <= 0 {
+				return mErrors.ErrInvalidSuppliedData("protobuf varint")
+			}
+			field(number, value, nil)
+			data = data[n:]
+		case 1, 5:
+			size := 8
+			if key&7 == 5 {
+				size = 4
+			}
+			if len(data) < size {
+				return mErrors.ErrInvalidSuppliedData("protobuf fixed field")
+			}
+			data = data[size:]
+		case 2:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return mErrors.ErrInvalidSuppliedData("protobuf length")
+			}
+			field(number, 0, data[n:n+int(length)])
+			data = data[n+int(length):]
+		default:
+			return mErrors.ErrInvalidSuppliedData("protobuf wire type")
+		}
+	}
+	return nil
+}
+
+func float64At(b []byte) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+func parseGyro(data []byte) []GyroRecord {
+	records := []GyroRecord{}
+	for p := 0; p+gyroRecordSize <= len(data); p += gyroRecordSize {
+		entry := data[p : p+gyroRecordSize]
+		record := GyroRecord{Time: time.Duration(binary.LittleEndian.Uint64(entry)) * time.Millisecond}
+		for i := 0; i < 3; i++ {
+			record.Accelerometer[i] = float64At(entry[8+i*8:])
+			record.Gyroscope[i] = float64At(entry[32+i*8:])
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func parseExposure(data []byte) []ExposureRecord {
+	records := []ExposureRecord{}
+	for p := 0; p+exposureRecordSize <= len(data); p += exposureRecordSize {
+		records = append(records, ExposureRecord{
+			Time:     time.Duration(binary.LittleEndian.Uint64(data[p:])) * time.Millisecond,
+			Exposure: float64At(data[p+8:]),
+		})
+	}
+	return records
+}
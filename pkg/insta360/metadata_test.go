@@ -0,0 +1,88 @@
+package insta360
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func protoString(field int, value string) []byte {
+	return append([]byte{byte(field<<3 | 2), byte(len(value))}, value...)
+}
+
+func protoVarint(field int, value uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return append([]byte{byte(field << 3)}, buf[:binary.PutUvarint(buf, value)]...)
+}
+
+func TestReadMetadata(t *testing.T) {
+	captured := time.Date(2023, 4, 2, 9, 15, 30, 0, time.UTC)
+	info := protoString(infoSerialNumber, "IXSE42A0ABCDEF")
+	info = append(info, protoString(infoModel, "Insta360 X3")...)
+	info = append(info, protoString(infoFirmware, "v1.0.04_build1")...)
+	info = append(info, 0x25, 1, 2, 3, 4) // fixed32 field 4 is skipped
+	info = append(info, protoString(infoLensOffset, "2_1486.590_1492.920_1497.410")...)
+	info = append(info, protoVarint(infoCaptureTime, uint64(captured.UnixMilli()))...)
+
+	gyro := make([]byte, gyroRecordSize)
+	binary.LittleEndian.PutUint64(gyro, 1500)
+	binary.LittleEndian.PutUint64(gyro[8:], math.Float64bits(-0.98))
+	binary.LittleEndian.PutUint64(gyro[48:], math.Float64bits(0.25))
+
+	exposure := make([]byte, exposureRecordSize)
+	binary.LittleEndian.PutUint64(exposure, 33)
+	binary.LittleEndian.PutUint64(exposure[8:], math.Float64bits(1.0/240))
+
+	path := writeTrailerFile(t, "VID_20230402_091530_00_001.insv",
+		Record{ID: RecordInfo, Data: info},
+		Record{ID: RecordGyro, Data: gyro},
+		Record{ID: RecordExposure, Data: exposure},
+	)
+
+	metadata, err := ReadMetadata(path)
+	require.NoError(t, err)
+	require.Equal(t, "IXSE42A0ABCDEF", metadata.SerialNumber)
+	require.Equal(t, "Insta360 X3", metadata.CameraName())
+	require.Equal(t, "v1.0.04_build1", metadata.Firmware)
+	require.Equal(t, "2_1486.590_1492.920_1497.410", metadata.LensOffset)
+	require.True(t, captured.Equal(metadata.CaptureTime))
+
+	require.Len(t, metadata.Gyro, 1)
+	require.Equal(t, 1500*time.Millisecond, metadata.Gyro[0].Time)
+	require.Equal(t, -0.98, metadata.Gyro[0].Accelerometer[0])
+	require.Equal(t, 0.25, metadata.Gyro[0].Gyroscope[2])
+	require.Len(t, metadata.Exposure, 1)
+	require.Equal(t, 1.0/240, metadata.Exposure[0].Exposure)
+
+	infoOnly, err := ReadMetadata(path, RecordInfo)
+	require.NoError(t, err)
+	require.Equal(t, "Insta360 X3", infoOnly.Model)
+	require.Empty(t, infoOnly.Gyro)
+
+	require.Error(t, readProtobuf([]byte{0x12, 0x10, 'X'}, func(int, uint64, []byte) {}))
+}
+
+func TestCameraName(t *testing.T) {
+	require.Equal(t, "Insta360 OneX2", Metadata{Model: "OneX2"}.CameraName())
+	require.Equal(t, "", Metadata{}.CameraName())
+}
+
+func TestGetDeviceName(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "fileinfo_list.list")
+	content := append([]byte{0x0a, 0x03, 'a', 'b', 'c'}, protoString(2, "Insta360 X3")...)
+	content = append(content, protoString(3, "v1.0.04")...)
+	require.NoError(t, os.WriteFile(manifest, content, 0o600))
+
+	name, ok := getDeviceName(manifest)
+	require.True(t, ok)
+	require.Equal(t, "Insta360 X3", name)
+
+	name, ok = getDeviceName(filepath.Join(t.TempDir(), "missing.list"))
+	require.False(t, ok)
+	require.Equal(t, "Insta360 Camera", name)
+}
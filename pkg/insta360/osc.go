@@ -0,0 +1,145 @@
+package insta360
+
+/* Open Spherical Camera (OSC) API exposed by Insta360 cameras over WiFi */
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/konradit/mmt/pkg/utils"
+)
+
+// OSCAddress is where the camera answers once connected to its WiFi
+const OSCAddress = "192.168.42.1"
+
+const oscPageSize = 50
+
+type OSCInfo struct {
+	Manufacturer    string `json:"manufacturer"`
+	Model           string `json:"model"`
+	SerialNumber    string `json:"serialNumber"`
+	FirmwareVersion string `json:"firmwareVersion"`
+}
+
+type OSCFile struct {
+	Name         string  `json:"name"`
+	FileURL      string  `json:"fileUrl"`
+	Size         int64   `json:"size"`
+	DateTimeZone string  `json:"dateTimeZone"` // 2022:10:12 10:27:25+02:00, the zone is left out by some firmwares
+	Latitude     float64 `json:"lat"`
+	Longitude    float64 `json:"lng"`
+}
+
+// Time is when the file was captured, in the camera's time zone
+func (f OSCFile) Time() (time.Time, error) {
+	t, err := time.Parse("2006:01:02 15:04:05-07:00", f.DateTimeZone)
+	if err != nil {
+		return time.ParseInLocation("2006:01:02 15:04:05", f.DateTimeZone, time.Local)
+	}
+	return t, nil
+}
+
+type oscCommand struct {
+	Name       string      `json:"name"`
+	Parameters interface{} `json:"parameters"`
+}
+
+type oscResponse struct {
+	Name    string          `json:"name"`
+	State   string          `json:"state"`
+	Results json.RawMessage `json:"results"`
+	Error   *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type oscListFiles struct {
+	Entries      []OSCFile `json:"entries"`
+	TotalEntries int       `json:"totalEntries"`
+}
+
+type oscClient struct {
+	host string
+}
+
+func (c oscClient) do(ctx context.Context, method, path string, body, object interface{}) error {
+	payload := &bytes.Buffer{}
+	if body != nil {
+		if err := json.NewEncoder(payload).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s/%s", c.host, path), payload)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json;charset=utf-8")
+	req.Header.Set("X-XSRF-Protected", "1")
+	resp, err := utils.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(object)
+}
+
+func (c oscClient) Info(ctx context.Context) (*OSCInfo, error) {
+	info := &OSCInfo{}
+	if err := c.do(ctx, "GET", "osc/info", nil, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (c oscClient) execute(ctx context.Context, name string, parameters, results interface{}) error {
+	response := &oscResponse{}
+	if err := c.do(ctx, "POST", "osc/commands/execute", oscCommand{Name: name, Parameters: parameters}, response); err != nil {
+		return err
+	}
+	if response.State != "done" {
+		if response.Error != nil {
+			return fmt.Errorf("%s: %s %s", name, response.Error.Code, response.Error.Message)
+		}
+		return fmt.Errorf("%s: %s", name, response.State)
+	}
+	return json.Unmarshal(response.Results, results)
+}
+
+// ListFiles pages through camera.listFiles until every file on the card is listed
+func (c oscClient) ListFiles(ctx context.Context) ([]OSCFile, error) {
+	files := []OSCFile{}
+	for {
+		page := &oscListFiles{}
+		err := c.execute(ctx, "camera.listFiles", map[string]interface{}{
+			"fileType":      "all",
+			"startPosition": len(files),
+			"entryCount":    oscPageSize,
+			"maxThumbSize":  0,
+		}, page)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, page.Entries...)
+		if len(page.Entries) == 0 || len(files) >= page.TotalEntries {
+			return files, nil
+		}
+	}
+}
+
+// MediaURL is the fileUrl of the file, relative ones are resolved against the camera
+func (c oscClient) MediaURL(file OSCFile) string {
+	if strings.HasPrefix(file.FileURL, "http://") || strings.HasPrefix(file.FileURL, "https://") {
+		return file.FileURL
+	}
+	return fmt.Sprintf("http://%s/%s", c.host, strings.TrimPrefix(file.FileURL, "/"))
+}
@@ -2,14 +2,12 @@ package insta360
 
 import (
 	"regexp"
+	"strings"
+	"unicode"
 
 	mErrors "github.com/konradit/mmt/pkg/errors"
 )
 
-type Metadata struct {
-	Model        string
-	SerialNumber string
-}
 type Item struct {
 	IsMininumVersion bool   `json:"is_mininum_version"`
 	WebsiteVisible   bool   `json:"website_visible"`
@@ -64,6 +62,7 @@ const (
 	Photo              FileType = "photo"
 	LowResolutionVideo FileType = "lrv"
 	RawPhoto           FileType = "dng"
+	Thumbnail          FileType = "thm"
 )
 
 type FileTypeMatch struct {
@@ -85,35 +84,56 @@ type File struct {
 type Camera string
 
 const (
-	OneR  Camera = "insta360-oner"
-	OneX  Camera = "insta360-onex"
-	OneX2 Camera = "insta360-onex2"
-	Go2   Camera = "insta360-go2"
+	OneR   Camera = "insta360-oner"
+	OneX   Camera = "insta360-onex"
+	OneX2  Camera = "insta360-onex2"
+	Go2    Camera = "insta360-go2"
+	X3     Camera = "insta360-x3"
+	X4     Camera = "insta360-x4"
+	Go3    Camera = "insta360-go3"
+	Ace    Camera = "insta360-ace"
+	AcePro Camera = "insta360-ace-pro"
 )
 
-func (e Camera) String() string {
-	extensions := [...]string{"insta360-oner", "insta360-onex", "insta360-onex2", "insta360-go2"}
+var cameras = [...]Camera{OneR, OneX, OneX2, Go2, X3, X4, Go3, Ace, AcePro}
+
+// SingleLens is true for the cameras recording flat video only, their .insv clips are flat too and wait for FlowState in Studio
+func (e Camera) SingleLens() bool {
+	switch e {
+	case Go2, Go3, Ace, AcePro:
+		return true
+	}
+	return false
+}
 
-	x := string(e)
-	for _, v := range extensions {
-		if v == x {
-			return x
+func (e Camera) String() string {
+	for _, v := range cameras {
+		if v == e {
+			return string(e)
 		}
 	}
 
 	return ""
 }
 
+// cameraKey reduces "insta360-ace-pro", "Insta360 Ace Pro" or "ONE X2" to the same key, eg: acepro, onex2
+func cameraKey(s string) string {
+	key := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+	return strings.TrimPrefix(key, "insta360")
+}
+
+// CameraGet accepts a camera ID (insta360-x3) or the model name cameras write to their manifest and files (Insta360 X3)
 func CameraGet(s string) (Camera, error) {
-	switch s {
-	case OneR.String():
-		return OneR, nil
-	case OneX2.String():
-		return OneX2, nil
-	case OneX.String():
-		return OneX, nil
-	case Go2.String():
-		return Go2, nil
+	key := cameraKey(s)
+	for _, camera := range cameras {
+		if key != "" && cameraKey(string(camera)) == key {
+			return camera, nil
+		}
 	}
 	return OneX, mErrors.ErrUnsupportedCamera(s)
 }
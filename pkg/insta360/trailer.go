@@ -0,0 +1,157 @@
+package insta360
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"time"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+)
+
+/*
+.insv, .insp and Insta360 .mp4 files end with a trailer of metadata records followed by a 78 byte footer:
+the trailer length (footer included) as a little endian uint32 at offset 38 and a 32 byte magic string at the end.
+Each record is its data followed by a 6 byte header: record ID (uint16) and data length (uint32), so the
+records are read from the footer backwards.
+*/
+const (
+	trailerMagic      = "8db42d694ccc418790edff439fe026bf"
+	trailerFooterSize = 78
+	recordHeaderSize  = 6
+)
+
+// Trailer record IDs
+const (
+	RecordInfo     uint16 = 0x101
+	RecordPreview  uint16 = 0x200
+	RecordGyro     uint16 = 0x300
+	RecordExposure uint16 = 0x400
+	RecordGPS      uint16 = 0x700
+)
+
+type Record struct {
+	ID   uint16
+	Data []byte
+}
+
+/*
+ReadTrailer returns the trailer records of an Insta360 file in the order they are stored.
+When ids are given only those records are read, gyro records alone take megabytes on long clips.
+*/
+func ReadTrailer(path string, ids ...uint16) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := stat.Size()
+	if size < trailerFooterSize {
+		return nil, mErrors.ErrNotFound("Insta360 trailer")
+	}
+
+	footer := make([]byte, trailerFooterSize)
+	if _, err := f.ReadAt(footer, size-trailerFooterSize); err != nil {
+		return nil, err
+	}
+	if string(footer[trailerFooterSize-len(trailerMagic):]) != trailerMagic {
+		return nil, mErrors.ErrNotFound("Insta360 trailer")
+	}
+	length := int64(binary.LittleEndian.Uint32(footer[38:]))
+	if length < trailerFooterSize || length > size {
+		return nil, mErrors.ErrInvalidSuppliedData("Insta360 trailer length")
+	}
+	return readRecords(f, size-length, size-trailerFooterSize, ids)
+}
+
+func readRecords(r io.ReaderAt, start, end int64, ids []uint16) ([]Record, error) {
+	wanted := func(id uint16) bool {
+		if len(ids) == 0 {
+			return true
+		}
+		for _, candidate := range ids {
+			if candidate == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	records := []Record{}
+	header := make([]byte, recordHeaderSize)
+	for end-start >= recordHeaderSize {
+		if _, err := r.ReadAt(header, end-recordHeaderSize); err != nil {
+			return nil, err
+		}
+		id := binary.LittleEndian.Uint16(header)
+		length := int64(binary.LittleEndian.Uint32(header[2:]))
+		dataStart := end - recordHeaderSize - length
+		if length == 0 || dataStart < start {
+			break
+		}
+		if wanted(id) {
+			data := make([]byte, length)
+			if _, err := r.ReadAt(data, dataStart); err != nil {
+				return nil, err
+			}
+			records = append([]Record{{ID: id, Data: data}}, records...)
+		}
+		end = dataStart
+	}
+	return records, nil
+}
+
+/*
+GPSRecord is a 53 byte 0x700 entry: unix time in seconds (uint64) and milliseconds (uint16), fix 'A' or 'V',
+latitude, 'N' or 'S', longitude, 'E' or 'W', then speed (m/s), track and altitude, all float64.
+*/
+type GPSRecord struct {
+	Time      time.Time
+	Valid     bool
+	Latitude  float64
+	Longitude float64
+	Speed     float64
+	Track     float64
+	Altitude  float64
+}
+
+const gpsRecordSize = 53
+
+func parseGPS(data []byte) []GPSRecord {
+	records := []GPSRecord{}
+	for p := 0; p+gpsRecordSize <= len(data); p += gpsRecordSize {
+		entry := data[p : p+gpsRecordSize]
+		record := GPSRecord{
+			Time:      time.Unix(int64(binary.LittleEndian.Uint64(entry)), int64(binary.LittleEndian.Uint16(entry[8:]))*int64(time.Millisecond)).UTC(),
+			Valid:     entry[10] == 'A',
+			Latitude:  float64At(entry[11:]),
+			Longitude: float64At(entry[20:]),
+			Speed:     float64At(entry[29:]),
+			Track:     float64At(entry[37:]),
+			Altitude:  float64At(entry[45:]),
+		}
+		if entry[19] == 'S' {
+			record.Latitude = -record.Latitude
+		}
+		if entry[28] == 'W' {
+			record.Longitude = -record.Longitude
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// GPS collects the GPS records of every 0x700 record
+func GPS(records []Record) []GPSRecord {
+	gps := []GPSRecord{}
+	for _, record := range records {
+		if record.ID == RecordGPS {
+			gps = append(gps, parseGPS(record.Data)...)
+		}
+	}
+	return gps
+}
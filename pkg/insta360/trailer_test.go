@@ -0,0 +1,86 @@
+package insta360
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func gpsEntry(t time.Time, fix byte, lat float64, latRef byte, lon float64, lonRef byte, altitude float64) []byte {
+	entry := make([]byte, gpsRecordSize)
+	binary.LittleEndian.PutUint64(entry, uint64(t.Unix()))
+	binary.LittleEndian.PutUint16(entry[8:], uint16(t.Nanosecond()/int(time.Millisecond)))
+	entry[10] = fix
+	binary.LittleEndian.PutUint64(entry[11:], math.Float64bits(lat))
+	entry[19] = latRef
+	binary.LittleEndian.PutUint64(entry[20:], math.Float64bits(lon))
+	entry[28] = lonRef
+	binary.LittleEndian.PutUint64(entry[45:], math.Float64bits(altitude))
+	return entry
+}
+
+// writeTrailerFile appends records and the footer to some media bytes
+func writeTrailerFile(t *testing.T, name string, records ...Record) string {
+	trailer := []byte{}
+	for _, record := range records {
+		header := make([]byte, recordHeaderSize)
+		binary.LittleEndian.PutUint16(header, record.ID)
+		binary.LittleEndian.PutUint32(header[2:], uint32(len(record.Data)))
+		trailer = append(append(trailer, record.Data...), header...)
+	}
+	footer := make([]byte, trailerFooterSize)
+	binary.LittleEndian.PutUint32(footer[38:], uint32(len(trailer)+trailerFooterSize))
+	copy(footer[trailerFooterSize-len(trailerMagic):], trailerMagic)
+
+	path := filepath.Join(t.TempDir(), name)
+	content := append(append([]byte("ftyp media data"), trailer...), footer...)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return path
+}
+
+func TestReadTrailerGPS(t *testing.T) {
+	start := time.Date(2022, 10, 12, 10, 27, 25, 500*int(time.Millisecond), time.UTC)
+	gps := append(
+		gpsEntry(start, 'V', 0, 'N', 0, 'E', 0),
+		gpsEntry(start.Add(time.Second), 'A', 40.5894, 'N', 4.1479, 'W', 1032.5)...,
+	)
+	path := writeTrailerFile(t, "VID_20221012_102725_00_586.insv",
+		Record{ID: 0x101, Data: []byte{0x0a, 0x02, 'X', '3'}},
+		Record{ID: RecordGPS, Data: gps},
+	)
+
+	records, err := ReadTrailer(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, uint16(0x101), records[0].ID)
+
+	points := GPS(records)
+	require.Len(t, points, 2)
+	require.False(t, points[0].Valid)
+	require.True(t, points[1].Valid)
+	require.Equal(t, start.Add(time.Second), points[1].Time)
+	require.Equal(t, 40.5894, points[1].Latitude)
+	require.Equal(t, -4.1479, points[1].Longitude)
+	require.Equal(t, 1032.5, points[1].Altitude)
+
+	location, err := LocationService{}.GetLocation(path)
+	require.NoError(t, err)
+	require.Equal(t, 40.5894, location.Latitude)
+	require.Equal(t, -4.1479, location.Longitude)
+}
+
+func TestReadTrailerWithoutGPS(t *testing.T) {
+	path := writeTrailerFile(t, "VID_20221012_102725_00_586.insv", Record{ID: 0x101, Data: []byte{0x0a, 0x00}})
+	_, err := LocationService{}.GetLocation(path)
+	require.Error(t, err)
+
+	plain := filepath.Join(t.TempDir(), "VID_20221012_102725_10_586.insv")
+	require.NoError(t, os.WriteFile(plain, make([]byte, 200), 0o600))
+	_, err = ReadTrailer(plain)
+	require.Error(t, err)
+}
@@ -14,8 +14,18 @@ import (
 
 var FirmwareCatalogRemoteURL = "https://openapi.insta360.com/website/appDownload/getGroupApp?group=%s&X-Language=en-us"
 
+// UpdateCamera downloads the latest firmware to the card, the model is detected from the card when empty
 func UpdateCamera(sdcard string, model string) error {
-	camera, err := CameraGet("insta360-" + model)
+	var camera Camera
+	var err error
+	if model == "" {
+		camera, err = DetectModel(sdcard)
+		if err == nil {
+			color.Cyan("📷 Detected %s", camera)
+		}
+	} else {
+		camera, err = CameraGet("insta360-" + model)
+	}
 	if err != nil {
 		return err
 	}
@@ -63,6 +63,12 @@ func CameraGuess(input string) string {
 	if err == nil {
 		return DJI.ToString()
 	}
+
+	// Ace, Ace Pro and GO 3 cards have no manifest
+	_, err = os.Stat(filepath.Join(input, "DCIM", "Camera01"))
+	if err == nil {
+		return Insta360.ToString()
+	}
 	return ""
 }
 
@@ -212,6 +218,61 @@ func DownloadFile(filepath string, url string, progressbar *mpb.Bar) error {
 	return os.Rename(filepath+".tmp", filepath)
 }
 
+/*
+ResumeDownload downloads url to filepath like DownloadFile, but keeps the partial .tmp file of an
+interrupted download and asks the server for the remaining bytes only.
+*/
+func ResumeDownload(filepath string, url string, progressbar *mpb.Bar) error {
+	offset := int64(0)
+	if info, err := os.Stat(filepath + ".tmp"); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := http.DefaultClient.Do(req) // #nosec
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// the partial file is already whole
+		return os.Rename(filepath+".tmp", filepath)
+	case resp.StatusCode >= http.StatusBadRequest:
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	default:
+		offset = 0
+	}
+
+	out, err := os.OpenFile(filepath+".tmp", flags, 0o644)
+	if err != nil {
+		return err
+	}
+	var body io.Reader = resp.Body
+	if progressbar != nil {
+		progressbar.SetCurrent(offset)
+		proxyReader := progressbar.ProxyReader(resp.Body)
+		defer proxyReader.Close()
+		body = proxyReader
+	}
+	if _, err = io.Copy(out, body); err != nil {
+		out.Close()
+		return err
+	}
+	out.Close()
+	return os.Rename(filepath+".tmp", filepath)
+}
+
 func Unzip(src string, dest string) error {
 	r, err := zip.OpenReader(src)
 	if err != nil {
@@ -320,12 +381,12 @@ const (
 	Percentage
 )
 
-func GetNewBar(progressBar *mpb.Progress, total int64, filename string, barType BarType) *mpb.Bar {
+func GetNewBar(progressBar *mpb.Progress, total int64, filename string, barType BarType, options ...mpb.BarOption) *mpb.Bar {
 	decorator := decor.CountersKiloByte("% .2f / % .2f")
 	if barType == Percentage {
 		decorator = decor.Percentage(decor.WCSyncSpace)
 	}
-	return progressBar.AddBar(total,
+	return progressBar.AddBar(total, append([]mpb.BarOption{
 		mpb.PrependDecorators(
 			decor.Name(color.CyanString(fmt.Sprintf("%s: ", filename))),
 			decorator,
@@ -335,6 +396,7 @@ func GetNewBar(progressBar *mpb.Progress, total int64, filename string, barType
 				decor.EwmaETA(decor.ET_STYLE_GO, 60, decor.WCSyncWidth), "✔️",
 			),
 		),
+	}, options...)...,
 	)
 }
 
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// ContactSheetCell is a thumbnail with the label printed below it
+type ContactSheetCell struct {
+	Image image.Image
+	Label string
+}
+
+const sheetPadding = 12
+
+var (
+	labelFace       = basicfont.Face7x13
+	labelHeight     = labelFace.Height + 8
+	sheetBackground = color.NRGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff}
+	sheetText       = color.NRGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff}
+)
+
+// drawLabel prints text from x, y and cuts it off at maxWidth
+func drawLabel(dst draw.Image, x, y, maxWidth int, text string) {
+	runes := []rune(text)
+	if fits := (maxWidth - x) / labelFace.Advance; len(runes) > fits {
+		if fits < 0 {
+			fits = 0
+		}
+		runes = runes[:fits]
+	}
+	drawer := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(sheetText),
+		Face: labelFace,
+		Dot:  fixed.P(x, y+labelFace.Ascent),
+	}
+	drawer.DrawString(string(runes))
+}
+
+/*
+ContactSheet lays out rows of cells on a dark background, each cell cellWidth wide.
+Thumbnails are expected to be cellWidth wide already, a row is as tall as its tallest thumbnail.
+*/
+func ContactSheet(rows [][]ContactSheetCell, cellWidth int) *image.NRGBA {
+	columns := 0
+	height := sheetPadding
+	rowHeights := make([]int, len(rows))
+	for i, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+		for _, cell := range row {
+			if h := cell.Image.Bounds().Dy(); h > rowHeights[i] {
+				rowHeights[i] = h
+			}
+		}
+		height += rowHeights[i] + labelHeight + sheetPadding
+	}
+	width := sheetPadding + columns*(cellWidth+sheetPadding)
+
+	sheet := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(sheetBackground), image.Point{}, draw.Src)
+
+	y := sheetPadding
+	for i, row := range rows {
+		for j, cell := range row {
+			x := sheetPadding + j*(cellWidth+sheetPadding)
+			bounds := cell.Image.Bounds()
+			draw.Draw(sheet, image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy()), cell.Image, bounds.Min, draw.Src)
+			drawLabel(sheet, x, y+rowHeights[i]+4, x+cellWidth, cell.Label)
+		}
+		y += rowHeights[i] + labelHeight + sheetPadding
+	}
+	return sheet
+}
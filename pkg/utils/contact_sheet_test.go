@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContactSheet(t *testing.T) {
+	wide := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	tall := image.NewNRGBA(image.Rect(0, 0, 40, 30))
+	sheet := ContactSheet([][]ContactSheetCell{
+		{{Image: wide, Label: "GOPR0001 with a label far wider than its cell.JPG"}, {Image: tall, Label: "warm 50%"}},
+		{{Image: wide, Label: "GOPR0002.JPG"}},
+	}, 40)
+
+	require.Equal(t, sheetPadding+2*(40+sheetPadding), sheet.Bounds().Dx())
+	require.Equal(t, sheetPadding+(30+labelHeight+sheetPadding)+(20+labelHeight+sheetPadding), sheet.Bounds().Dy())
+
+	// the first thumbnail is black, the background and labels are not
+	require.Equal(t, color.NRGBA{}, sheet.NRGBAAt(sheetPadding, sheetPadding))
+	require.Equal(t, sheetBackground, sheet.NRGBAAt(0, 0))
+	labelled := false
+	for x := sheetPadding; x < sheetPadding+40; x++ {
+		for y := sheetPadding + 30; y < sheetPadding+30+labelHeight; y++ {
+			labelled = labelled || sheet.NRGBAAt(x, y) == sheetText
+		}
+	}
+	require.True(t, labelled)
+
+	// long labels are cut off at the edge of their cell
+	for x := sheetPadding + 40; x < 2*sheetPadding+40; x++ {
+		for y := sheetPadding + 30; y < sheetPadding+30+labelHeight; y++ {
+			require.Equal(t, sheetBackground, sheet.NRGBAAt(x, y))
+		}
+	}
+}
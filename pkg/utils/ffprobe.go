@@ -53,10 +53,44 @@ type GPSLocation struct {
 type StreamsResponse struct {
 	Streams []struct {
 		Index          int    `json:"index"`
+		CodecName      string `json:"codec_name"`
+		CodecType      string `json:"codec_type"`
 		CodecTagString string `json:"codec_tag_string"`
+		Width          int    `json:"width"`
+		Height         int    `json:"height"`
+		RFrameRate     string `json:"r_frame_rate"`
+		SampleRate     string `json:"sample_rate"`
+		Channels       int    `json:"channels"`
 	} `json:"streams"`
 }
 
+type PacketsResponse struct {
+	Packets []struct {
+		PtsTime float64 `json:"pts_time,string"`
+		Flags   string  `json:"flags"`
+	} `json:"packets"`
+}
+
+// ParseFrameRate converts an ffprobe rational frame rate (eg: 30000/1001) into frames per second
+func ParseFrameRate(rate string) (float64, error) {
+	parts := strings.Split(rate, "/")
+	numerator, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	if len(parts) == 1 {
+		return numerator, nil
+	}
+	denominator, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	if denominator == 0 {
+		return 0, mErrors.ErrInvalidSuppliedData(rate)
+	}
+	return numerator / denominator, nil
+}
+
 func NewFFprobe(path *string) FFprobe {
 	ff := FFprobe{}
 	if path == nil {
@@ -180,6 +214,43 @@ func (f *FFprobe) Duration(path string) (*DurationResponse, error) {
 	return &result, nil
 }
 
+func (f *FFprobe) KeyFrames(path string) ([]float64, error) {
+	result := PacketsResponse{}
+
+	args := []string{
+		"-select_streams",
+		"v:0",
+		"-show_entries",
+		"packet=pts_time,flags",
+		"-of",
+		"json",
+		path,
+	}
+	_, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(f.ProgramPath, args...) // #nosec
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err = cmd.Run()
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(out.Bytes(), &result)
+	if err != nil {
+		return nil, err
+	}
+
+	keyframes := []float64{}
+	for _, packet := range result.Packets {
+		if strings.Contains(packet.Flags, "K") {
+			keyframes = append(keyframes, packet.PtsTime)
+		}
+	}
+	return keyframes, nil
+}
+
 func (f *FFprobe) GPSLocation(path string) (*Location, error) {
 	result := GPSLocation{}
 	out, err := f.executeGetFormat(path)
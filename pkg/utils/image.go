@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+	"golang.org/x/image/tiff"
+)
+
+// Image formats DecodeImage and EncodeImage understand
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatTIFF = "tiff"
+)
+
+var (
+	exifHeader   = []byte("Exif\x00\x00")
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// maxAPP1 is the largest EXIF blob that fits in a JPEG APP1 segment next to the Exif header
+const maxAPP1 = 0xffff - 2 - 6
+
+// ImageFormat picks the format from the extension, case insensitive
+func ImageFormat(path string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		return FormatJPEG, true
+	case ".png":
+		return FormatPNG, true
+	case ".tif", ".tiff":
+		return FormatTIFF, true
+	}
+	return "", false
+}
+
+// DecodeImage reads a JPEG, PNG or TIFF, 16 bit PNGs and TIFFs decode to 16 bit images
+func DecodeImage(path string) (image.Image, string, error) {
+	format, ok := ImageFormat(path)
+	if !ok {
+		return nil, "", mErrors.ErrUnrecognizedMediaFormat
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var img image.Image
+	switch format {
+	case FormatJPEG:
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case FormatPNG:
+		img, err = png.Decode(bytes.NewReader(data))
+	case FormatTIFF:
+		img, err = tiff.Decode(bytes.NewReader(data))
+	}
+	return img, format, err
+}
+
+// ReadEXIF returns the EXIF of a JPEG, PNG or TIFF as a TIFF structure, nil when there is none
+func ReadEXIF(path string) ([]byte, error) {
+	format, ok := ImageFormat(path)
+	if !ok {
+		return nil, mErrors.ErrUnrecognizedMediaFormat
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatJPEG:
+		return jpegEXIF(data), nil
+	case FormatPNG:
+		return pngEXIF(data), nil
+	}
+	return minimalEXIF(data), nil
+}
+
+func jpegEXIF(data []byte) []byte {
+	for i := 2; i+4 <= len(data) && data[i] == 0xff; {
+		marker := data[i+1]
+		if marker == 0xda || marker == 0xd9 {
+			return nil
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end > len(data) {
+			return nil
+		}
+		segment := data[i+4 : end]
+		if marker == 0xe1 && bytes.HasPrefix(segment, exifHeader) {
+			return segment[len(exifHeader):]
+		}
+		i = end
+	}
+	return nil
+}
+
+func pngEXIF(data []byte) []byte {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil
+	}
+	for i := len(pngSignature); i+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 8 + length + 4
+		if end > len(data) {
+			return nil
+		}
+		if string(data[i+4:i+8]) == "eXIf" {
+			return data[i+8 : i+8+length]
+		}
+		i = end
+	}
+	return nil
+}
+
+// EncodeImage writes img in format with exifBlob embedded, quality only applies to JPEGs
+func EncodeImage(path string, img image.Image, format string, quality int, exifBlob []byte) error {
+	buffer := bytes.Buffer{}
+	var err error
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		err = png.Encode(&buffer, img)
+	case FormatTIFF:
+		err = encodeTIFF(&buffer, img, exifBlob)
+	default:
+		err = mErrors.ErrUnrecognizedMediaFormat
+	}
+	if err != nil {
+		return err
+	}
+
+	data := buffer.Bytes()
+	if len(exifBlob) > 0 {
+		switch format {
+		case FormatJPEG:
+			data = embedJPEGEXIF(data, exifBlob)
+		case FormatPNG:
+			data = embedPNGEXIF(data, exifBlob)
+		}
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// embedJPEGEXIF adds an APP1 segment after SOI, blobs too large for one are written without their thumbnail
+func embedJPEGEXIF(data, exifBlob []byte) []byte {
+	if len(exifBlob) > maxAPP1 {
+		exifBlob = minimalEXIF(exifBlob)
+		if len(exifBlob) > maxAPP1 {
+			return data
+		}
+	}
+	segment := make([]byte, 4, 4+len(exifHeader)+len(exifBlob))
+	segment[0], segment[1] = 0xff, 0xe1
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(exifHeader)+len(exifBlob)))
+	segment = append(append(segment, exifHeader...), exifBlob...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// embedPNGEXIF adds an eXIf chunk before the first IDAT
+func embedPNGEXIF(data, exifBlob []byte) []byte {
+	for i := len(pngSignature); i+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		if string(data[i+4:i+8]) != "IDAT" {
+			i += 8 + length + 4
+			continue
+		}
+		chunk := make([]byte, 8, 12+len(exifBlob))
+		binary.BigEndian.PutUint32(chunk, uint32(len(exifBlob)))
+		copy(chunk[4:], "eXIf")
+		chunk = append(chunk, exifBlob...)
+		crc := make([]byte, 4)
+		binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(chunk[4:]))
+		chunk = append(chunk, crc...)
+
+		out := append([]byte{}, data[:i]...)
+		out = append(out, chunk...)
+		return append(out, data[i:]...)
+	}
+	return data
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/rwcarlsen/goexif/tiff"
+	"github.com/stretchr/testify/require"
+)
+
+// testEXIF is a big endian EXIF block with a Make tag and a GPS IFD holding GPSLatitudeRef
+func testEXIF() []byte {
+	order := binary.BigEndian
+	gps := ifd{{id: 1, typ: tiff.DTAscii, count: 2, value: []byte("N\x00")}}
+	ifd0 := ifd{
+		{id: 271, typ: tiff.DTAscii, count: 6, value: []byte("GoPro\x00")},
+		longEntry(tagGPSIFD, order, 0),
+	}
+	ifd0[1] = longEntry(tagGPSIFD, order, uint32(8+ifd0.size()))
+
+	out := bytes.Buffer{}
+	out.WriteString("MM\x00\x2a\x00\x00\x00\x08")
+	ifd0.write(&out, 8, order)
+	gps.write(&out, out.Len(), order)
+	return out.Bytes()
+}
+
+func TestEncodeImageKeepsEXIF(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	img.SetNRGBA(1, 2, color.NRGBA{R: 200, A: 255})
+	folder := t.TempDir()
+
+	for _, name := range []string{"photo.JPG", "photo.png", "photo.tiff"} {
+		path := filepath.Join(folder, name)
+		format, ok := ImageFormat(path)
+		require.True(t, ok)
+		require.NoError(t, EncodeImage(path, img, format, 90, testEXIF()))
+
+		decoded, decodedFormat, err := DecodeImage(path)
+		require.NoError(t, err, name)
+		require.Equal(t, format, decodedFormat)
+		require.Equal(t, 4, decoded.Bounds().Dx())
+
+		blob, err := ReadEXIF(path)
+		require.NoError(t, err)
+		tf, err := tiff.Decode(bytes.NewReader(blob))
+		require.NoError(t, err, name)
+		camera, err := findTag(tf.Dirs[0], 271).StringVal()
+		require.NoError(t, err)
+		require.Equal(t, "GoPro", camera)
+
+		gps := exifSubIFD(blob, tf.Dirs[0], tagGPSIFD, tf.Order)
+		require.NotNil(t, gps, name)
+		ref, err := findTag(gps, 1).StringVal()
+		require.NoError(t, err)
+		require.Equal(t, "N", ref)
+	}
+}
+
+func TestReadEXIFWithoutEXIF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.png")
+	require.NoError(t, EncodeImage(path, image.NewGray(image.Rect(0, 0, 2, 2)), FormatPNG, 0, nil))
+	blob, err := ReadEXIF(path)
+	require.NoError(t, err)
+	require.Nil(t, blob)
+}
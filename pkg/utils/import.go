@@ -5,13 +5,23 @@ import "time"
 type ImportParams struct {
 	Input, Output, CameraName string
 	SkipAuxiliaryFiles        bool
+	SkipIncomplete            bool
+	Sidecars                  bool
 	DateFormat                string
 	BufferSize                int
 	Prefix                    string
 	DateRange                 []time.Time
 	TagNames                  []string
+	DevicePaths               []string
 	Connection                ConnectionType
 	Sort                      SortOptions
+	Sequences                 SequenceOptions
+	Chapters                  ChapterOptions
+	RawMode                   RawMode
+}
+
+type ChapterOptions struct {
+	Merge, Keep bool
 }
 
 type Import interface {
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"bufio"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+	"github.com/wayneashleyberry/lut/pkg/cubelut"
+)
+
+// ReadLUT loads a 3D LUT from a .cube, a .3dl or a Hald CLUT image (.png, .tif)
+func ReadLUT(path string) (cubelut.CubeFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cubelut.CubeFile{}, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cube":
+		return cubelut.Parse(bufio.NewReader(f))
+	case ".3dl":
+		return parseThreeDL(bufio.NewScanner(f))
+	case ".png", ".tif", ".tiff":
+		img, _, err := DecodeImage(path)
+		if err != nil {
+			return cubelut.CubeFile{}, err
+		}
+		return parseHaldCLUT(img)
+	}
+	return cubelut.CubeFile{}, mErrors.ErrInvalidSuppliedData(filepath.Base(path))
+}
+
+func newCubeFile(size int) cubelut.CubeFile {
+	return cubelut.CubeFile{
+		Dimensions: 3,
+		DomainMin:  []float64{0, 0, 0},
+		DomainMax:  []float64{1, 1, 1},
+		Size:       size,
+		R:          make([]float64, size*size*size),
+		G:          make([]float64, size*size*size),
+		B:          make([]float64, size*size*size),
+	}
+}
+
+/*
+parseThreeDL reads Autodesk/Lustre .3dl files: an optional shaper line with the input values,
+then one integer RGB triplet per entry with blue changing fastest. Output depth comes from the
+Mesh header when present, otherwise from the largest value.
+*/
+func parseThreeDL(scanner *bufio.Scanner) (cubelut.CubeFile, error) {
+	entries := [][3]float64{}
+	outputBits := 0
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if strings.EqualFold(fields[0], "Mesh") && len(fields) == 3 {
+			outputBits, _ = strconv.Atoi(fields[2])
+			continue
+		}
+		if len(fields) != 3 {
+			continue
+		}
+		entry := [3]float64{}
+		valid := true
+		for i, field := range fields {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			entry[i] = value
+		}
+		if valid {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return cubelut.CubeFile{}, err
+	}
+
+	size := int(math.Round(math.Cbrt(float64(len(entries)))))
+	if size < 2 || size*size*size != len(entries) {
+		return cubelut.CubeFile{}, mErrors.ErrInvalidSuppliedData("3dl LUT size")
+	}
+
+	maxValue := math.Pow(2, float64(outputBits)) - 1
+	if outputBits == 0 {
+		largest := 0.0
+		for _, entry := range entries {
+			largest = math.Max(largest, math.Max(entry[0], math.Max(entry[1], entry[2])))
+		}
+		for _, bits := range []float64{10, 12, 14, 16} {
+			maxValue = math.Pow(2, bits) - 1
+			if largest <= maxValue {
+				break
+			}
+		}
+	}
+
+	cf := newCubeFile(size)
+	for i, entry := range entries {
+		r, g, b := i/(size*size), i/size%size, i%size
+		index := r + size*g + size*size*b
+		cf.R[index] = entry[0] / maxValue
+		cf.G[index] = entry[1] / maxValue
+		cf.B[index] = entry[2] / maxValue
+	}
+	return cf, nil
+}
+
+// parseHaldCLUT reads a Hald CLUT of level L: an L³×L³ image holding an L² cube, red changing fastest
+func parseHaldCLUT(img image.Image) (cubelut.CubeFile, error) {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	level := int(math.Round(math.Cbrt(float64(width))))
+	if width != bounds.Dy() || level*level*level != width || level < 2 {
+		return cubelut.CubeFile{}, mErrors.ErrInvalidSuppliedData("Hald CLUT size")
+	}
+
+	size := level * level
+	cf := newCubeFile(size)
+	for i := range cf.R {
+		c := color.NRGBA64Model.Convert(img.At(bounds.Min.X+i%width, bounds.Min.Y+i/width)).(color.NRGBA64)
+		cf.R[i] = float64(c.R) / 0xffff
+		cf.G[i] = float64(c.G) / 0xffff
+		cf.B[i] = float64(c.B) / 0xffff
+	}
+	return cf, nil
+}
+
+func is16Bit(img image.Image) bool {
+	switch img.(type) {
+	case *image.RGBA64, *image.NRGBA64, *image.Gray16:
+		return true
+	}
+	return false
+}
+
+// lookup interpolates the LUT trilinearly at r, g, b between 0 and 1
+func lookup(cf cubelut.CubeFile, r, g, b float64) (float64, float64, float64) {
+	n := cf.Size
+	scale := func(v float64, channel int) float64 {
+		v = (v - cf.DomainMin[channel]) / (cf.DomainMax[channel] - cf.DomainMin[channel])
+		return math.Min(math.Max(v, 0), 1) * float64(n-1)
+	}
+	x, y, z := scale(r, 0), scale(g, 1), scale(b, 2)
+	x0, y0, z0 := int(x), int(y), int(z)
+	x1, y1, z1 := minInt(x0+1, n-1), minInt(y0+1, n-1), minInt(z0+1, n-1)
+	dx, dy, dz := x-float64(x0), y-float64(y0), z-float64(z0)
+
+	at := func(table []float64) float64 {
+		c00 := table[x0+n*y0+n*n*z0]*(1-dx) + table[x1+n*y0+n*n*z0]*dx
+		c10 := table[x0+n*y1+n*n*z0]*(1-dx) + table[x1+n*y1+n*n*z0]*dx
+		c01 := table[x0+n*y0+n*n*z1]*(1-dx) + table[x1+n*y0+n*n*z1]*dx
+		c11 := table[x0+n*y1+n*n*z1]*(1-dx) + table[x1+n*y1+n*n*z1]*dx
+		return (c00*(1-dy)+c10*dy)*(1-dz) + (c01*(1-dy)+c11*dy)*dz
+	}
+	return at(cf.R), at(cf.G), at(cf.B)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// ApplyLUT grades img at intensity between 0 and 1, 16 bit images stay 16 bit
+func ApplyLUT(cf cubelut.CubeFile, img image.Image, intensity float64) (image.Image, error) {
+	if intensity < 0 || intensity > 1 {
+		return nil, mErrors.ErrInvalidSuppliedData(intensity)
+	}
+	if cf.Size < 2 || len(cf.R) != cf.Size*cf.Size*cf.Size {
+		return nil, mErrors.ErrInvalidSuppliedData("LUT size")
+	}
+
+	bounds := img.Bounds()
+	rect := image.Rect(0, 0, bounds.Dx(), bounds.Dy())
+	wide := is16Bit(img)
+	var out16 *image.NRGBA64
+	var out8 *image.NRGBA
+	if wide {
+		out16 = image.NewNRGBA64(rect)
+	} else {
+		out8 = image.NewNRGBA(rect)
+	}
+
+	blend := func(original uint16, graded float64) float64 {
+		v := float64(original)/0xffff*(1-intensity) + graded*intensity
+		return math.Min(math.Max(v, 0), 1)
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			r, g, b := lookup(cf, float64(c.R)/0xffff, float64(c.G)/0xffff, float64(c.B)/0xffff)
+			r, g, b = blend(c.R, r), blend(c.G, g), blend(c.B, b)
+			if wide {
+				out16.SetNRGBA64(x-bounds.Min.X, y-bounds.Min.Y, color.NRGBA64{
+					R: uint16(math.Round(r * 0xffff)), G: uint16(math.Round(g * 0xffff)), B: uint16(math.Round(b * 0xffff)), A: c.A,
+				})
+				continue
+			}
+			out8.SetNRGBA(x-bounds.Min.X, y-bounds.Min.Y, color.NRGBA{
+				R: uint8(math.Round(r * 0xff)), G: uint8(math.Round(g * 0xff)), B: uint8(math.Round(b * 0xff)), A: uint8(c.A >> 8),
+			})
+		}
+	}
+	if wide {
+		return out16, nil
+	}
+	return out8, nil
+}
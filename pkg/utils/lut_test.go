@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"bufio"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseThreeDL(t *testing.T) {
+	// 2x2x2 inverting LUT, blue changes fastest
+	lut := `0 1023
+Mesh 1 12
+4095 4095 4095
+4095 4095 0
+4095 0 4095
+4095 0 0
+0 4095 4095
+0 4095 0
+0 0 4095
+0 0 0
+`
+	cf, err := parseThreeDL(bufio.NewScanner(strings.NewReader(lut)))
+	require.NoError(t, err)
+	require.Equal(t, 2, cf.Size)
+
+	// cube order, red changes fastest: index 1 is r=1 g=0 b=0
+	require.Equal(t, []float64{0, 1, 1}, []float64{cf.R[1], cf.G[1], cf.B[1]})
+	require.Equal(t, []float64{1, 1, 0}, []float64{cf.R[4], cf.G[4], cf.B[4]})
+
+	_, err = parseThreeDL(bufio.NewScanner(strings.NewReader("0 0 0\n1 1 1\n")))
+	require.Error(t, err)
+}
+
+func identityHald(level int) *image.NRGBA {
+	size := level * level
+	width := size * level
+	img := image.NewNRGBA(image.Rect(0, 0, width, width))
+	for i := 0; i < size*size*size; i++ {
+		r, g, b := i%size, i/size%size, i/size/size
+		img.SetNRGBA(i%width, i/width, color.NRGBA{
+			R: uint8(r * 255 / (size - 1)), G: uint8(g * 255 / (size - 1)), B: uint8(b * 255 / (size - 1)), A: 255,
+		})
+	}
+	return img
+}
+
+func TestParseHaldCLUT(t *testing.T) {
+	cf, err := parseHaldCLUT(identityHald(2))
+	require.NoError(t, err)
+	require.Equal(t, 4, cf.Size)
+	require.InDelta(t, 1.0/3, cf.R[1], 0.01)
+	require.InDelta(t, 1.0/3, cf.G[4], 0.01)
+	require.InDelta(t, 1.0/3, cf.B[16], 0.01)
+
+	_, err = parseHaldCLUT(image.NewNRGBA(image.Rect(0, 0, 10, 10)))
+	require.Error(t, err)
+}
+
+func TestApplyLUT(t *testing.T) {
+	cf, err := parseHaldCLUT(identityHald(3))
+	require.NoError(t, err)
+
+	src := image.NewNRGBA64(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA64(0, 0, color.NRGBA64{R: 0x1234, G: 0x8000, B: 0xfedc, A: 0xffff})
+	src.SetNRGBA64(1, 0, color.NRGBA64{R: 0xffff, A: 0x8000})
+
+	graded, err := ApplyLUT(cf, src, 1)
+	require.NoError(t, err)
+	wide, ok := graded.(*image.NRGBA64)
+	require.True(t, ok, "16 bit images stay 16 bit")
+	got := wide.NRGBA64At(0, 0)
+	require.InDelta(t, 0x1234, int(got.R), 0x100)
+	require.InDelta(t, 0x8000, int(got.G), 0x100)
+	require.InDelta(t, 0xfedc, int(got.B), 0x100)
+	require.Equal(t, uint16(0x8000), wide.NRGBA64At(1, 0).A)
+
+	graded, err = ApplyLUT(cf, image.NewRGBA(image.Rect(0, 0, 1, 1)), 0.5)
+	require.NoError(t, err)
+	require.IsType(t, &image.NRGBA{}, graded)
+
+	_, err = ApplyLUT(cf, src, 2)
+	require.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+)
+
+// RawMode is what is kept of photos shot as raw+JPEG pairs
+type RawMode string
+
+const (
+	RawSeparate RawMode = "separate" // raws in photos/raw, the default
+	RawPair     RawMode = "pair"     // raws side by side with their JPEG
+	RawOnly     RawMode = "raw"      // the JPEG of a pair is skipped
+	JPEGOnly    RawMode = "jpeg"     // the raw of a pair is skipped
+)
+
+var rawModes = []RawMode{RawSeparate, RawPair, RawOnly, JPEGOnly}
+
+var (
+	rawExtensions  = []string{".GPR", ".DNG"}
+	jpegExtensions = []string{".JPG", ".JPEG", ".INSP"}
+)
+
+func RawModeGet(s string) (RawMode, error) {
+	if s == "" {
+		return RawSeparate, nil
+	}
+	for _, mode := range rawModes {
+		if strings.EqualFold(s, string(mode)) {
+			return mode, nil
+		}
+	}
+	return "", mErrors.ErrInvalidSuppliedData(s)
+}
+
+func IsRaw(path string) bool {
+	for _, extension := range rawExtensions {
+		if strings.EqualFold(filepath.Ext(path), extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// RawSibling finds the other half of a raw+JPEG pair: the JPEG of a raw or the raw of a JPEG, same name and folder
+func RawSibling(path string) (string, bool) {
+	candidates := rawExtensions
+	if IsRaw(path) {
+		candidates = jpegExtensions
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, extension := range candidates {
+		for _, sibling := range []string{base + extension, base + strings.ToLower(extension)} {
+			if _, err := os.Stat(sibling); err == nil {
+				return sibling, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Keep tells whether a photo is imported, paired being whether it has a raw or JPEG sibling
+func (m RawMode) Keep(raw, paired bool) bool {
+	switch m {
+	case RawOnly:
+		return raw || !paired
+	case JPEGOnly:
+		return !raw || !paired
+	}
+	return true
+}
+
+// KeepFile is Keep for a photo on the card, its sibling looked up next to it
+func (m RawMode) KeepFile(path string) bool {
+	_, paired := RawSibling(path)
+	return m.Keep(IsRaw(path), paired)
+}
+
+/*
+WritesSidecar tells whether a photo writes its sidecar, alongside being whether its raw or JPEG sibling is imported
+to the same folder: both would write X.xmp, the pair shares the one of the raw.
+*/
+func WritesSidecar(raw, alongside bool) bool {
+	return raw || !alongside
+}
+
+// Alongside tells whether both photos of a raw+JPEG pair are imported to the same folder
+func (m RawMode) Alongside(paired bool) bool {
+	return paired && m == RawPair
+}
+
+// WritesSidecarFile is WritesSidecar for a photo on the card, its sibling looked up next to it
+func (m RawMode) WritesSidecarFile(path string) bool {
+	_, paired := RawSibling(path)
+	return WritesSidecar(IsRaw(path), m.Alongside(paired))
+}
+
+// RawFolder is where raws go given the photos folder: their own subfolder unless kept with the JPEGs
+func (m RawMode) RawFolder(photos string) string {
+	if m == RawSeparate || m == "" {
+		return filepath.Join(photos, "raw")
+	}
+	return photos
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawModeGet(t *testing.T) {
+	mode, err := RawModeGet("")
+	require.NoError(t, err)
+	require.Equal(t, RawSeparate, mode)
+
+	mode, err = RawModeGet("JPEG")
+	require.NoError(t, err)
+	require.Equal(t, JPEGOnly, mode)
+
+	_, err = RawModeGet("tiff")
+	require.Error(t, err)
+}
+
+func TestRawSibling(t *testing.T) {
+	folder := t.TempDir()
+	for _, name := range []string{"GOPR0001.JPG", "GOPR0001.GPR", "GOPR0002.JPG", "IMG_00_012.insp", "IMG_00_012.dng"} {
+		require.NoError(t, os.WriteFile(filepath.Join(folder, name), []byte(name), 0o600))
+	}
+
+	sibling, found := RawSibling(filepath.Join(folder, "GOPR0001.JPG"))
+	require.True(t, found)
+	require.Equal(t, filepath.Join(folder, "GOPR0001.GPR"), sibling)
+	sibling, found = RawSibling(filepath.Join(folder, "IMG_00_012.dng"))
+	require.True(t, found)
+	require.Equal(t, filepath.Join(folder, "IMG_00_012.insp"), sibling)
+	_, found = RawSibling(filepath.Join(folder, "GOPR0002.JPG"))
+	require.False(t, found)
+
+	require.False(t, RawOnly.KeepFile(filepath.Join(folder, "GOPR0001.JPG")))
+	require.True(t, RawOnly.KeepFile(filepath.Join(folder, "GOPR0002.JPG")))
+	require.False(t, JPEGOnly.KeepFile(filepath.Join(folder, "GOPR0001.GPR")))
+	require.True(t, RawPair.KeepFile(filepath.Join(folder, "GOPR0001.GPR")))
+
+	// side by side the pair shares the sidecar of its raw
+	require.False(t, RawPair.WritesSidecarFile(filepath.Join(folder, "GOPR0001.JPG")))
+	require.True(t, RawPair.WritesSidecarFile(filepath.Join(folder, "GOPR0001.GPR")))
+	require.True(t, RawPair.WritesSidecarFile(filepath.Join(folder, "GOPR0002.JPG")))
+	require.True(t, RawSeparate.WritesSidecarFile(filepath.Join(folder, "GOPR0001.JPG")))
+
+	require.Equal(t, filepath.Join("photos", "raw"), RawSeparate.RawFolder("photos"))
+	require.Equal(t, "photos", RawPair.RawFolder("photos"))
+}
@@ -2,10 +2,14 @@ package utils
 
 import (
 	"fmt"
+	"math"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/codingsince1985/geo-golang"
 	"github.com/codingsince1985/geo-golang/openstreetmap"
+	mErrors "github.com/konradit/mmt/pkg/errors"
 	"github.com/spf13/viper"
 )
 
@@ -66,10 +70,60 @@ func getPrettyAddress(format locationFormat, address *geo.Address) string {
 	return cleanup(format.format(address))
 }
 
-func ReverseLocation(location Location) (string, error) {
-	service := openstreetmap.Geocoder()
+var (
+	addressesMu sync.Mutex
+	addresses   = map[[2]float64]*geo.Address{}
+
+	// Nominatim allows a request per second, importers copying in parallel wait their turn here
+	lookupMu       sync.Mutex
+	lastLookup     time.Time
+	lookupInterval = time.Second
+	reverseGeocode = func(lat, lng float64) (*geo.Address, error) {
+		return openstreetmap.Geocoder().ReverseGeocode(lat, lng)
+	}
+)
+
+func cachedAddress(key [2]float64) (*geo.Address, bool) {
+	addressesMu.Lock()
+	defer addressesMu.Unlock()
+	address, found := addresses[key]
+	return address, found
+}
+
+// ReverseAddress looks up the address of location, files shot within about a hundred meters share the lookup
+func ReverseAddress(location Location) (*geo.Address, error) {
+	key := [2]float64{math.Round(location.Latitude*1000) / 1000, math.Round(location.Longitude*1000) / 1000}
+	if address, found := cachedAddress(key); found {
+		return address, nil
+	}
+
+	lookupMu.Lock()
+	defer lookupMu.Unlock()
+	// filled while waiting by a file shot at the same place
+	if address, found := cachedAddress(key); found {
+		return address, nil
+	}
+	if wait := time.Until(lastLookup.Add(lookupInterval)); wait > 0 {
+		time.Sleep(wait)
+	}
+	lastLookup = time.Now()
 
-	address, err := service.ReverseGeocode(location.Latitude, location.Longitude)
+	address, err := reverseGeocode(location.Latitude, location.Longitude)
+	if err != nil {
+		return nil, err
+	}
+	if address == nil {
+		return nil, mErrors.ErrNotFound(fmt.Sprintf("address of %f,%f", location.Latitude, location.Longitude))
+	}
+
+	addressesMu.Lock()
+	addresses[key] = address
+	addressesMu.Unlock()
+	return address, nil
+}
+
+func ReverseLocation(location Location) (string, error) {
+	address, err := ReverseAddress(location)
 	if err != nil {
 		return "", err
 	}
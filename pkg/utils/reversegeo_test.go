@@ -2,8 +2,11 @@ package utils
 
 import (
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/codingsince1985/geo-golang"
 	"github.com/codingsince1985/geo-golang/openstreetmap"
 	"github.com/stretchr/testify/require"
 )
@@ -48,3 +51,34 @@ func TestPrettyAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestReverseAddressRateLimit(t *testing.T) {
+	addressesMu.Lock()
+	addresses = map[[2]float64]*geo.Address{}
+	addressesMu.Unlock()
+	geocode, interval := reverseGeocode, lookupInterval
+	defer func() { reverseGeocode, lookupInterval = geocode, interval }()
+
+	lookups := []time.Time{}
+	reverseGeocode = func(lat, lng float64) (*geo.Address, error) {
+		lookups = append(lookups, time.Now())
+		return &geo.Address{City: fmt.Sprintf("%.3f", lat)}, nil
+	}
+	lookupInterval = 50 * time.Millisecond
+
+	// files copied in parallel, shot at two places
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			address, err := ReverseAddress(Location{Latitude: 10 + float64(i%2), Longitude: 20})
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("%.3f", 10+float64(i%2)), address.City)
+		}(i)
+	}
+	wg.Wait()
+
+	require.Len(t, lookups, 2)
+	require.GreaterOrEqual(t, lookups[1].Sub(lookups[0]), lookupInterval)
+}
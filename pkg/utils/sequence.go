@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+)
+
+const SequenceFile = "sequence.json"
+
+type SequenceOptions struct {
+	Render    bool
+	FrameRate int
+}
+
+// Sequence describes a folder of frames shot as one burst, timelapse or panorama
+type Sequence struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type,omitempty"`
+	Frames    int       `json:"frames"`
+	Interval  float64   `json:"interval"`
+	First     time.Time `json:"first"`
+	Last      time.Time `json:"last"`
+	FirstFile string    `json:"first_file"`
+	LastFile  string    `json:"last_file"`
+	Render    string    `json:"render,omitempty"`
+	Result    string    `json:"result,omitempty"` // the stitched panorama or rendered video the camera made from the frames
+	files     []string
+}
+
+// Files returns the frames in order
+func (s *Sequence) Files() []string {
+	return s.files
+}
+
+// ReadSequence builds a Sequence from the files in folder with one of extensions, their modification time being the capture time
+func ReadSequence(folder, name, kind string, extensions ...string) (*Sequence, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	type frame struct {
+		path string
+		time time.Time
+	}
+	frames := []frame{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := false
+		for _, extension := range extensions {
+			matches = matches || strings.EqualFold(filepath.Ext(entry.Name()), extension)
+		}
+		if !matches {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame{path: filepath.Join(folder, entry.Name()), time: info.ModTime()})
+	}
+	if len(frames) == 0 {
+		return nil, mErrors.ErrNotFound(folder)
+	}
+	sort.Slice(frames, func(i, j int) bool { return frames[i].path < frames[j].path })
+
+	first, last := frames[0], frames[len(frames)-1]
+	sequence := &Sequence{
+		Name:      name,
+		Type:      kind,
+		Frames:    len(frames),
+		First:     first.time,
+		Last:      last.time,
+		FirstFile: filepath.Base(first.path),
+		LastFile:  filepath.Base(last.path),
+	}
+	if len(frames) > 1 {
+		sequence.Interval = last.time.Sub(first.time).Seconds() / float64(len(frames)-1)
+	}
+	for _, f := range frames {
+		sequence.files = append(sequence.files, f.path)
+	}
+	return sequence, nil
+}
+
+// Write saves the sequence as sequence.json in folder
+func (s *Sequence) Write(folder string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(folder, SequenceFile), b, 0o600)
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSequence(t *testing.T) {
+	folder := t.TempDir()
+	start := time.Date(2023, 10, 19, 18, 0, 0, 0, time.UTC)
+	for name, offset := range map[string]time.Duration{
+		"G0010001.JPG": 0,
+		"G0010002.jpg": 3 * time.Second,
+		"G0010003.JPG": 8 * time.Second,
+		"notes.txt":    time.Minute,
+	} {
+		path := filepath.Join(folder, name)
+		require.NoError(t, os.WriteFile(path, []byte("frame"), 0o600))
+		require.NoError(t, os.Chtimes(path, start, start.Add(offset)))
+	}
+
+	sequence, err := ReadSequence(folder, "G001", "burst", ".JPG")
+	require.NoError(t, err)
+	require.Equal(t, 3, sequence.Frames)
+	require.Equal(t, 4.0, sequence.Interval)
+	require.Equal(t, "G0010001.JPG", sequence.FirstFile)
+	require.Equal(t, "G0010003.JPG", sequence.LastFile)
+	require.True(t, sequence.First.Equal(start))
+	require.Len(t, sequence.Files(), 3)
+
+	require.NoError(t, sequence.Write(folder))
+	content, err := os.ReadFile(filepath.Join(folder, SequenceFile))
+	require.NoError(t, err)
+	written := Sequence{}
+	require.NoError(t, json.Unmarshal(content, &written))
+	require.Equal(t, "burst", written.Type)
+	require.Equal(t, 3, written.Frames)
+
+	_, err = ReadSequence(t.TempDir(), "empty", "", ".JPG")
+	require.Error(t, err)
+}
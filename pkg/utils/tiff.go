@@ -0,0 +1,285 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+// TIFF tags written by the encoder
+const (
+	tagImageWidth      = 256
+	tagImageLength     = 257
+	tagBitsPerSample   = 258
+	tagCompression     = 259
+	tagPhotometric     = 262
+	tagStripOffsets    = 273
+	tagSamplesPerPixel = 277
+	tagRowsPerStrip    = 278
+	tagStripByteCounts = 279
+	tagPlanarConfig    = 284
+	tagExtraSamples    = 338
+	tagExifIFD         = 34665
+	tagGPSIFD          = 34853
+	tagInteropIFD      = 40965
+)
+
+// descriptiveTags are the IFD0 tags copied from the source EXIF into a written TIFF
+var descriptiveTags = map[uint16]bool{
+	270:   true, // ImageDescription
+	271:   true, // Make
+	272:   true, // Model
+	274:   true, // Orientation
+	282:   true, // XResolution
+	283:   true, // YResolution
+	296:   true, // ResolutionUnit
+	305:   true, // Software
+	306:   true, // DateTime
+	315:   true, // Artist
+	33432: true, // Copyright
+}
+
+func findTag(dir *tiff.Dir, id uint16) *tiff.Tag {
+	for _, tag := range dir.Tags {
+		if tag.Id == id {
+			return tag
+		}
+	}
+	return nil
+}
+
+type ifdEntry struct {
+	id    uint16
+	typ   tiff.DataType
+	count uint32
+	value []byte
+}
+
+type ifd []ifdEntry
+
+func (d ifd) dataSize(entry ifdEntry) int {
+	if len(entry.value) <= 4 {
+		return 0
+	}
+	return len(entry.value) + len(entry.value)%2
+}
+
+// size is the IFD itself followed by the values that don't fit in an entry
+func (d ifd) size() int {
+	size := 2 + 12*len(d) + 4
+	for _, entry := range d {
+		size += d.dataSize(entry)
+	}
+	return size
+}
+
+func (d ifd) write(w *bytes.Buffer, offset int, order binary.ByteOrder) {
+	sort.Slice(d, func(i, j int) bool { return d[i].id < d[j].id })
+	data := offset + 2 + 12*len(d) + 4
+
+	scratch := make([]byte, 4)
+	order.PutUint16(scratch, uint16(len(d)))
+	w.Write(scratch[:2])
+	for _, entry := range d {
+		order.PutUint16(scratch, entry.id)
+		w.Write(scratch[:2])
+		order.PutUint16(scratch, uint16(entry.typ))
+		w.Write(scratch[:2])
+		order.PutUint32(scratch, entry.count)
+		w.Write(scratch)
+		if len(entry.value) <= 4 {
+			w.Write(append(entry.value, make([]byte, 4-len(entry.value))...))
+			continue
+		}
+		order.PutUint32(scratch, uint32(data))
+		w.Write(scratch)
+		data += d.dataSize(entry)
+	}
+	w.Write(make([]byte, 4))
+	for _, entry := range d {
+		if len(entry.value) > 4 {
+			w.Write(entry.value)
+			w.Write(make([]byte, d.dataSize(entry)-len(entry.value)))
+		}
+	}
+}
+
+func shortEntry(id uint16, order binary.ByteOrder, values ...uint16) ifdEntry {
+	value := make([]byte, 2*len(values))
+	for i, v := range values {
+		order.PutUint16(value[i*2:], v)
+	}
+	return ifdEntry{id: id, typ: tiff.DTShort, count: uint32(len(values)), value: value}
+}
+
+func longEntry(id uint16, order binary.ByteOrder, v uint32) ifdEntry {
+	value := make([]byte, 4)
+	order.PutUint32(value, v)
+	return ifdEntry{id: id, typ: tiff.DTLong, count: 1, value: value}
+}
+
+func toIFD(dir *tiff.Dir, keep func(id uint16) bool) ifd {
+	entries := ifd{}
+	for _, tag := range dir.Tags {
+		if keep(tag.Id) {
+			entries = append(entries, ifdEntry{id: tag.Id, typ: tag.Type, count: tag.Count, value: tag.Val})
+		}
+	}
+	return entries
+}
+
+// exifSubIFD reads the IFD a pointer tag of IFD0 refers to
+func exifSubIFD(blob []byte, dir *tiff.Dir, pointer uint16, order binary.ByteOrder) *tiff.Dir {
+	tag := findTag(dir, pointer)
+	if tag == nil {
+		return nil
+	}
+	offset, err := tag.Int64(0)
+	if err != nil {
+		return nil
+	}
+	r := bytes.NewReader(blob)
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil
+	}
+	sub, _, err := tiff.DecodeDir(r, order)
+	if err != nil {
+		return nil
+	}
+	return sub
+}
+
+// exifOrder is the byte order of exifBlob, little endian when there is none
+func exifOrder(exifBlob []byte) binary.ByteOrder {
+	if len(exifBlob) >= 2 && string(exifBlob[:2]) == "MM" {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+/*
+writeTIFFStructure writes a TIFF with ifd0 followed by pixels. The descriptive IFD0 tags and the
+Exif and GPS IFDs of exifBlob are copied along, exifBlob must be in order.
+*/
+func writeTIFFStructure(order binary.ByteOrder, ifd0 ifd, exifBlob, pixels []byte) []byte {
+	subIFDs := []ifd{}
+	pointers := []uint16{}
+	if source, err := tiff.Decode(bytes.NewReader(exifBlob)); err == nil && len(source.Dirs) > 0 {
+		ifd0 = append(ifd0, toIFD(source.Dirs[0], func(id uint16) bool { return descriptiveTags[id] })...)
+		for _, pointer := range []uint16{tagExifIFD, tagGPSIFD} {
+			sub := exifSubIFD(exifBlob, source.Dirs[0], pointer, source.Order)
+			if sub == nil {
+				continue
+			}
+			subIFDs = append(subIFDs, toIFD(sub, func(id uint16) bool { return id != tagInteropIFD }))
+			pointers = append(pointers, pointer)
+			ifd0 = append(ifd0, longEntry(pointer, order, 0))
+		}
+	}
+
+	setLong := func(id uint16, v int) {
+		for i := range ifd0 {
+			if ifd0[i].id == id {
+				ifd0[i] = longEntry(id, order, uint32(v))
+			}
+		}
+	}
+	offset := 8 + ifd0.size()
+	for i, sub := range subIFDs {
+		setLong(pointers[i], offset)
+		offset += sub.size()
+	}
+	setLong(tagStripOffsets, offset)
+
+	out := bytes.Buffer{}
+	if order == binary.BigEndian {
+		out.WriteString("MM\x00\x2a")
+	} else {
+		out.WriteString("II\x2a\x00")
+	}
+	header := make([]byte, 4)
+	order.PutUint32(header, 8)
+	out.Write(header)
+
+	ifd0.write(&out, 8, order)
+	for _, sub := range subIFDs {
+		sub.write(&out, out.Len(), order)
+	}
+	out.Write(pixels)
+	return out.Bytes()
+}
+
+// minimalEXIF keeps the descriptive tags and the Exif and GPS IFDs of a TIFF structure, dropping thumbnails and image data
+func minimalEXIF(blob []byte) []byte {
+	minimal := writeTIFFStructure(exifOrder(blob), ifd{}, blob, nil)
+	if len(minimal) <= 8+(ifd{}).size() {
+		return nil
+	}
+	return minimal
+}
+
+// encodeTIFF writes img as an uncompressed single strip RGB(A) TIFF, 8 or 16 bit like the image, with the EXIF of exifBlob
+func encodeTIFF(w io.Writer, img image.Image, exifBlob []byte) error {
+	order := exifOrder(exifBlob)
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	bits := 8
+	if is16Bit(img) {
+		bits = 16
+	}
+	samples := 3
+	if !isOpaque(img) {
+		samples = 4
+	}
+
+	pixels := make([]byte, 0, width*height*samples*bits/8)
+	scratch := make([]byte, 2)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			for _, v := range []uint16{c.R, c.G, c.B, c.A}[:samples] {
+				if bits == 8 {
+					pixels = append(pixels, uint8(v>>8))
+					continue
+				}
+				order.PutUint16(scratch, v)
+				pixels = append(pixels, scratch...)
+			}
+		}
+	}
+
+	bitsPerSample := make([]uint16, samples)
+	for i := range bitsPerSample {
+		bitsPerSample[i] = uint16(bits)
+	}
+	ifd0 := ifd{
+		longEntry(tagImageWidth, order, uint32(width)),
+		longEntry(tagImageLength, order, uint32(height)),
+		shortEntry(tagBitsPerSample, order, bitsPerSample...),
+		shortEntry(tagCompression, order, 1),
+		shortEntry(tagPhotometric, order, 2),
+		longEntry(tagStripOffsets, order, 0),
+		shortEntry(tagSamplesPerPixel, order, uint16(samples)),
+		longEntry(tagRowsPerStrip, order, uint32(height)),
+		longEntry(tagStripByteCounts, order, uint32(len(pixels))),
+		shortEntry(tagPlanarConfig, order, 1),
+	}
+	if samples == 4 {
+		ifd0 = append(ifd0, shortEntry(tagExtraSamples, order, 2))
+	}
+
+	_, err := w.Write(writeTIFFStructure(order, ifd0, exifBlob, pixels))
+	return err
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/tiff"
+)
+
+func TestTIFFRoundtrip(t *testing.T) {
+	narrow := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	narrow.SetNRGBA(1, 1, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	wide := image.NewNRGBA64(image.Rect(0, 0, 3, 2))
+	wide.SetNRGBA64(2, 0, color.NRGBA64{R: 0x0102, G: 0x0304, B: 0xfffe, A: 0x8000})
+
+	for _, src := range []image.Image{narrow, wide} {
+		buffer := bytes.Buffer{}
+		require.NoError(t, encodeTIFF(&buffer, src, nil))
+		decoded, err := tiff.Decode(&buffer)
+		require.NoError(t, err)
+		require.Equal(t, is16Bit(src), is16Bit(decoded))
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 3; x++ {
+				require.Equal(t, color.NRGBA64Model.Convert(src.At(x, y)), color.NRGBA64Model.Convert(decoded.At(x, y)))
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+)
+
+// NominalFrameRate returns the integer rate timecode counts in, eg: 29.97 -> 30
+func NominalFrameRate(fps float64) int {
+	nominal := int(math.Round(fps))
+	if nominal < 1 {
+		return 1
+	}
+	return nominal
+}
+
+// IsNTSC reports fractional rates such as 23.976, 29.97 or 59.94
+func IsNTSC(fps float64) bool {
+	return math.Abs(fps-math.Round(fps)) > 0.001
+}
+
+// SecondsToFrames rounds a position in seconds to the closest frame
+func SecondsToFrames(seconds, fps float64) int {
+	return int(math.Round(seconds * fps))
+}
+
+// FramesToTimecode returns a non-drop frame SMPTE timecode (HH:MM:SS:FF)
+func FramesToTimecode(frames int, fps float64) string {
+	nominal := NominalFrameRate(fps)
+	ff := frames % nominal
+	totalSeconds := frames / nominal
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", totalSeconds/3600, (totalSeconds/60)%60, totalSeconds%60, ff)
+}
+
+func splitMilliseconds(seconds float64) (int, int, int, int) {
+	ms := int(math.Round(seconds * 1000))
+	return ms / 3600000, (ms / 60000) % 60, (ms / 1000) % 60, ms % 1000
+}
+
+// SubRipTime formats seconds as used by SRT files (HH:MM:SS,mmm)
+func SubRipTime(seconds float64) string {
+	h, m, s, ms := splitMilliseconds(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
+}
+
+// WebVTTTime formats seconds as used by WebVTT files (HH:MM:SS.mmm)
+func WebVTTTime(seconds float64) string {
+	h, m, s, ms := splitMilliseconds(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+}
+
+// ChapterTime formats seconds as YouTube chapters expect (MM:SS or H:MM:SS)
+func ChapterTime(seconds float64) string {
+	h, m, s, _ := splitMilliseconds(math.Floor(seconds))
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimecode(t *testing.T) {
+	require.Equal(t, "00:00:01:00", FramesToTimecode(SecondsToFrames(1, 25), 25))
+	require.Equal(t, "00:00:18:26", FramesToTimecode(SecondsToFrames(18.88, 29.97), 29.97))
+	require.Equal(t, "01:00:00:00", FramesToTimecode(SecondsToFrames(3600, 60), 60))
+	require.Equal(t, 30, NominalFrameRate(29.97))
+	require.True(t, IsNTSC(23.976))
+	require.False(t, IsNTSC(50))
+
+	require.Equal(t, "00:01:02,500", SubRipTime(62.5))
+	require.Equal(t, "00:01:02.500", WebVTTTime(62.5))
+	require.Equal(t, "01:02", ChapterTime(62.5))
+	require.Equal(t, "1:00:05", ChapterTime(3605))
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XMP is the metadata of an imported file written to its sidecar, picked up by Lightroom, darktable and the like
+type XMP struct {
+	Make         string
+	Model        string
+	SerialNumber string
+	CreateDate   time.Time
+	Location     *Location
+	City         string
+	State        string
+	Country      string
+	Keywords     []string
+	Rating       int
+}
+
+/*
+WriteSidecar completes x with where path was shot, as read by locations, and the place names of that location
+before writing the sidecar of path. The location and places are left out when unknown.
+*/
+func WriteSidecar(locations locationUtil, path string, x XMP) error {
+	if x.Location == nil {
+		if location, err := locations.GetLocation(path); err == nil && location != nil {
+			x.Location = location
+		}
+	}
+	if x.Location != nil && x.City == "" && x.State == "" && x.Country == "" {
+		if address, err := ReverseAddress(*x.Location); err == nil {
+			x.City, x.State, x.Country = address.City, address.State, address.Country
+		}
+	}
+	return x.Write(path)
+}
+
+// HiLightRating turns the HiLight tags of a file into a star rating, one per tag up to five
+func HiLightRating(count int) int {
+	if count > 5 {
+		return 5
+	}
+	if count < 0 {
+		return 0
+	}
+	return count
+}
+
+// SidecarPath is the sidecar of path, the extension replaced the way Lightroom expects it, eg: GOPR0001.GPR => GOPR0001.xmp
+func SidecarPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".xmp"
+}
+
+// xmpCoordinate formats a coordinate as XMP GPSCoordinate: degrees, decimal minutes and the hemisphere, eg: 48,51.396N
+func xmpCoordinate(value float64, positive, negative string) string {
+	hemisphere := positive
+	if value < 0 {
+		hemisphere = negative
+		value = -value
+	}
+	degrees := math.Floor(value)
+	return fmt.Sprintf("%d,%.4f%s", int(degrees), (value-degrees)*60, hemisphere)
+}
+
+func (x XMP) attributes() [][2]string {
+	attributes := [][2]string{}
+	add := func(name, value string) {
+		if value != "" {
+			attributes = append(attributes, [2]string{name, value})
+		}
+	}
+	add("tiff:Make", x.Make)
+	add("tiff:Model", x.Model)
+	add("aux:SerialNumber", x.SerialNumber)
+	add("exifEX:BodySerialNumber", x.SerialNumber)
+	if !x.CreateDate.IsZero() {
+		date := x.CreateDate.Format("2006-01-02T15:04:05-07:00")
+		add("xmp:CreateDate", date)
+		add("exif:DateTimeOriginal", date)
+	}
+	if x.Rating > 0 {
+		add("xmp:Rating", strconv.Itoa(x.Rating))
+	}
+	if x.Location != nil {
+		add("exif:GPSVersionID", "2.2.0.0")
+		add("exif:GPSLatitude", xmpCoordinate(x.Location.Latitude, "N", "S"))
+		add("exif:GPSLongitude", xmpCoordinate(x.Location.Longitude, "E", "W"))
+	}
+	add("photoshop:City", x.City)
+	add("photoshop:State", x.State)
+	add("photoshop:Country", x.Country)
+	return attributes
+}
+
+var xmpNamespaces = [][2]string{
+	{"xmp", "http://ns.adobe.com/xap/1.0/"},
+	{"tiff", "http://ns.adobe.com/tiff/1.0/"},
+	{"exif", "http://ns.adobe.com/exif/1.0/"},
+	{"exifEX", "http://cipa.jp/exif/1.0/"},
+	{"aux", "http://ns.adobe.com/exif/1.0/aux/"},
+	{"photoshop", "http://ns.adobe.com/photoshop/1.0/"},
+	{"dc", "http://purl.org/dc/elements/1.1/"},
+}
+
+func xmlEscape(buffer *bytes.Buffer, s string) {
+	_ = xml.EscapeText(buffer, []byte(s))
+}
+
+// Marshal renders x as an XMP packet, properties as attributes of a single rdf:Description and keywords as dc:subject
+func (x XMP) Marshal() []byte {
+	buffer := &bytes.Buffer{}
+	buffer.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	buffer.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\" x:xmptk=\"mmt\">\n")
+	buffer.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	buffer.WriteString("  <rdf:Description rdf:about=\"\"")
+	for _, namespace := range xmpNamespaces {
+		buffer.WriteString("\n    xmlns:" + namespace[0] + "=\"" + namespace[1] + "\"")
+	}
+	for _, attribute := range x.attributes() {
+		buffer.WriteString("\n    " + attribute[0] + "=\"")
+		xmlEscape(buffer, attribute[1])
+		buffer.WriteString("\"")
+	}
+	if len(x.Keywords) == 0 {
+		buffer.WriteString("/>\n")
+	} else {
+		buffer.WriteString(">\n")
+		buffer.WriteString("   <dc:subject>\n")
+		buffer.WriteString("    <rdf:Bag>\n")
+		for _, keyword := range x.Keywords {
+			buffer.WriteString("     <rdf:li>")
+			xmlEscape(buffer, keyword)
+			buffer.WriteString("</rdf:li>\n")
+		}
+		buffer.WriteString("    </rdf:Bag>\n")
+		buffer.WriteString("   </dc:subject>\n")
+		buffer.WriteString("  </rdf:Description>\n")
+	}
+	buffer.WriteString(" </rdf:RDF>\n")
+	buffer.WriteString("</x:xmpmeta>\n")
+	buffer.WriteString("<?xpacket end=\"w\"?>\n")
+	return buffer.Bytes()
+}
+
+// Write writes the sidecar of the imported file at path
+func (x XMP) Write(path string) error {
+	return os.WriteFile(SidecarPath(path), x.Marshal(), 0o644)
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codingsince1985/geo-golang"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXMPCoordinate(t *testing.T) {
+	require.Equal(t, "48,51.3960N", xmpCoordinate(48.8566, "N", "S"))
+	require.Equal(t, "2,21.1320W", xmpCoordinate(-2.3522, "E", "W"))
+}
+
+type fakeLocations struct {
+	location *Location
+}
+
+func (f fakeLocations) GetLocation(path string) (*Location, error) {
+	return f.location, nil
+}
+
+func readXMP(t *testing.T, path string) (map[string]string, []string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var packet struct {
+		Description struct {
+			Attributes []xml.Attr `xml:",any,attr"`
+			Subject    []string   `xml:"subject>Bag>li"`
+		} `xml:"RDF>Description"`
+	}
+	require.NoError(t, xml.Unmarshal(content, &packet))
+	attributes := map[string]string{}
+	for _, attribute := range packet.Description.Attributes {
+		attributes[attribute.Name.Space+":"+attribute.Name.Local] = attribute.Value
+	}
+	return attributes, packet.Description.Subject
+}
+
+func TestXMPWrite(t *testing.T) {
+	folder := t.TempDir()
+	raw := filepath.Join(folder, "GOPR0001.GPR")
+	x := XMP{
+		Make:         "GoPro",
+		Model:        "HERO9 Black & co",
+		SerialNumber: "C3441325000001",
+		CreateDate:   time.Date(2023, 10, 19, 18, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		Location:     &Location{Latitude: 48.8566, Longitude: 2.3522},
+		Keywords:     []string{"good stuff", "<important>"},
+		Rating:       HiLightRating(7),
+	}
+	require.NoError(t, x.Write(raw))
+
+	attributes, keywords := readXMP(t, filepath.Join(folder, "GOPR0001.xmp"))
+	require.Equal(t, "GoPro", attributes["http://ns.adobe.com/tiff/1.0/:Make"])
+	require.Equal(t, "HERO9 Black & co", attributes["http://ns.adobe.com/tiff/1.0/:Model"])
+	require.Equal(t, "C3441325000001", attributes["http://ns.adobe.com/exif/1.0/aux/:SerialNumber"])
+	require.Equal(t, "C3441325000001", attributes["http://cipa.jp/exif/1.0/:BodySerialNumber"])
+	require.Equal(t, "2023-10-19T18:00:00+02:00", attributes["http://ns.adobe.com/xap/1.0/:CreateDate"])
+	require.Equal(t, "5", attributes["http://ns.adobe.com/xap/1.0/:Rating"])
+	require.Equal(t, "48,51.3960N", attributes["http://ns.adobe.com/exif/1.0/:GPSLatitude"])
+	require.Equal(t, "2,21.1320E", attributes["http://ns.adobe.com/exif/1.0/:GPSLongitude"])
+	require.Equal(t, []string{"good stuff", "<important>"}, keywords)
+
+	require.NotContains(t, string(XMP{Make: "DJI"}.Marshal()), "GPSLatitude")
+	require.NotContains(t, string(XMP{Make: "DJI"}.Marshal()), "dc:subject")
+}
+
+func TestWriteSidecar(t *testing.T) {
+	location := Location{Latitude: 40.5894, Longitude: -4.1476}
+	// looked up already, keeps the test offline
+	addresses[[2]float64{40.589, -4.148}] = &geo.Address{City: "San Lorenzo de El Escorial", State: "Comunidad de Madrid", Country: "España"}
+
+	video := filepath.Join(t.TempDir(), "GX010001.MP4")
+	require.NoError(t, WriteSidecar(fakeLocations{location: &location}, video, XMP{Make: "GoPro"}))
+
+	attributes, _ := readXMP(t, SidecarPath(video))
+	require.Equal(t, "40,35.3640N", attributes["http://ns.adobe.com/exif/1.0/:GPSLatitude"])
+	require.Equal(t, "4,8.8560W", attributes["http://ns.adobe.com/exif/1.0/:GPSLongitude"])
+	require.Equal(t, "San Lorenzo de El Escorial", attributes["http://ns.adobe.com/photoshop/1.0/:City"])
+	require.Equal(t, "Comunidad de Madrid", attributes["http://ns.adobe.com/photoshop/1.0/:State"])
+	require.Equal(t, "España", attributes["http://ns.adobe.com/photoshop/1.0/:Country"])
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"sync"
 
+	mErrors "github.com/konradit/mmt/pkg/errors"
 	"github.com/konradit/mmt/pkg/utils"
 	"github.com/vbauerster/mpb/v8"
 	"github.com/xfrr/goffmpeg/ffmpeg"
@@ -17,7 +18,8 @@ import (
 )
 
 type VMan struct {
-	trans *transcoder.Transcoder
+	trans     *transcoder.Transcoder
+	ffmpegBin string
 }
 
 // FFmpeg params
@@ -32,12 +34,13 @@ func New() *VMan {
 	conf.FfprobeBin = strings.Trim(conf.FfprobeBin, "\r")
 	conf.FfmpegBin = strings.Trim(conf.FfmpegBin, "\r")
 	v.trans.SetConfiguration(conf)
+	v.ffmpegBin = conf.FfmpegBin
 	return v
 }
 
 func (v *VMan) NewDefaultConfig() FFConfig {
 	return FFConfig{
-		UseHWAccel: true,
+		HWAccel:    resolveHWAccel(v.ffmpegBin),
 		AudioCodec: Copy,
 		VideoCodec: Copy,
 		InArgs:     []string{},
@@ -46,16 +49,17 @@ func (v *VMan) NewDefaultConfig() FFConfig {
 }
 
 type FFConfig struct {
-	UseHWAccel             bool
+	HWAccel                string // -hwaccel method, empty to decode in software
 	AudioCodec, VideoCodec string
 	InArgs, OutArgs        []string
 	OutFormat              string
 }
 
-func getMergedOutputFilename(video string) string {
-	return filepath.Join(filepath.Dir(video),
-		fmt.Sprintf("%s-merged%s", strings.Replace(filepath.Base(video), filepath.Ext(video), "", -1), filepath.Ext(video)),
-	)
+func (c FFConfig) hwAccelArgs() []string {
+	if c.HWAccel == "" {
+		return []string{}
+	}
+	return []string{"-hwaccel", c.HWAccel}
 }
 
 func (v *VMan) merge(output string, bar *mpb.Bar, ffConfig FFConfig, videos ...string) error {
@@ -64,9 +68,7 @@ func (v *VMan) merge(output string, bar *mpb.Bar, ffConfig FFConfig, videos ...s
 		return err
 	}
 
-	if ffConfig.UseHWAccel {
-		ffConfig.InArgs = append(ffConfig.InArgs, []string{"-hwaccel", "cuda"}...)
-	}
+	ffConfig.InArgs = append(ffConfig.InArgs, ffConfig.hwAccelArgs()...)
 
 	file, err := ioutil.TempFile(filepath.Dir(videos[0]), "filelist.*.txt")
 	if err != nil {
@@ -75,7 +77,7 @@ func (v *VMan) merge(output string, bar *mpb.Bar, ffConfig FFConfig, videos ...s
 	defer os.Remove(file.Name())
 
 	for _, video := range videos {
-		a := fmt.Sprintf("file '%s'\n", video)
+		a := fmt.Sprintf("file '%s'\n", strings.ReplaceAll(video, "'", `'\''`))
 		_, err = file.WriteString(a)
 		if err != nil {
 			return err
@@ -115,19 +117,219 @@ func (v *VMan) merge(output string, bar *mpb.Bar, ffConfig FFConfig, videos ...s
 	return err
 }
 
-func (v *VMan) Merge(bar *mpb.Bar, videos ...string) error {
+// MergeTo concatenates videos sharing the same stream layout into output, keeping every stream
+func (v *VMan) MergeTo(output string, bar *mpb.Bar, videos ...string) error {
 	mergeConfig := v.NewDefaultConfig()
 	mergeConfig.InArgs = append(mergeConfig.InArgs, []string{"-f", "concat", "-safe", "0", "-ignore_unknown"}...)
-	mergeConfig.OutArgs = append(mergeConfig.OutArgs, []string{"-map", "0:0", "-map", "0:1", "-map", "0:3"}...)
+	mergeConfig.OutArgs = append(mergeConfig.OutArgs, []string{"-map", "0", "-copy_unknown", "-tag:d", "gpmd"}...)
 
-	err := v.merge(getMergedOutputFilename(videos[0]), bar, mergeConfig, videos...)
-	if err != nil {
-		log.Fatal(err.Error())
+	return v.merge(output, bar, mergeConfig, videos...)
+}
+
+// StreamMapArgs maps every video and audio stream and the GPMF track of a GoPro video by index.
+// The timecode track can't be stream copied, the muxer rebuilds it with -write_tmcd.
+func StreamMapArgs(streams *utils.StreamsResponse) []string {
+	args := []string{}
+	output := 0
+	for _, stream := range streams.Streams {
+		switch {
+		case stream.CodecType == "video", stream.CodecType == "audio":
+			args = append(args, "-map", fmt.Sprintf("0:%d", stream.Index))
+		case stream.CodecTagString == "gpmd":
+			args = append(args, "-map", fmt.Sprintf("0:%d", stream.Index), fmt.Sprintf("-tag:%d", output), "gpmd")
+		default:
+			continue
+		}
+		output++
 	}
+	return append(args, "-c:d", Copy, "-copy_unknown", "-write_tmcd", "on")
+}
 
+// CheckStreams makes sure every video has the same stream layout as the first one, with matching codecs,
+// resolution and frame rate, so they can be concatenated without reencoding
+func CheckStreams(videos []string, streams []*utils.StreamsResponse) error {
+	first := streams[0].Streams
+	for i := 1; i < len(streams); i++ {
+		current := streams[i].Streams
+		if len(current) != len(first) {
+			return mErrors.ErrIncompatibleStreams(filepath.Base(videos[i]),
+				fmt.Sprintf("has %d streams, %s has %d", len(current), filepath.Base(videos[0]), len(first)))
+		}
+		for j, stream := range current {
+			expected := first[j]
+			mismatch := ""
+			switch {
+			case stream.CodecType != expected.CodecType, stream.CodecTagString != expected.CodecTagString:
+				mismatch = fmt.Sprintf("stream %d is %s/%s instead of %s/%s", j, stream.CodecType, stream.CodecTagString, expected.CodecType, expected.CodecTagString)
+			case stream.CodecName != expected.CodecName:
+				mismatch = fmt.Sprintf("stream %d is %s instead of %s", j, stream.CodecName, expected.CodecName)
+			case stream.Width != expected.Width, stream.Height != expected.Height:
+				mismatch = fmt.Sprintf("resolution is %dx%d instead of %dx%d", stream.Width, stream.Height, expected.Width, expected.Height)
+			case stream.RFrameRate != expected.RFrameRate:
+				mismatch = fmt.Sprintf("frame rate is %s instead of %s", stream.RFrameRate, expected.RFrameRate)
+			case stream.SampleRate != expected.SampleRate, stream.Channels != expected.Channels:
+				mismatch = fmt.Sprintf("audio is %sHz/%dch instead of %sHz/%dch", stream.SampleRate, stream.Channels, expected.SampleRate, expected.Channels)
+			}
+			if mismatch != "" {
+				return mErrors.ErrIncompatibleStreams(filepath.Base(videos[i]), mismatch)
+			}
+		}
+	}
 	return nil
 }
 
+// Merge losslessly joins videos into output after checking their streams match.
+// Video, audio and GPMF tracks are mapped from what ffprobe reports, the timecode track is rebuilt.
+func (v *VMan) Merge(output string, bar *mpb.Bar, videos ...string) error {
+	ffprobe := utils.NewFFprobe(nil)
+	streams := []*utils.StreamsResponse{}
+	for _, video := range videos {
+		s, err := ffprobe.Streams(video)
+		if err != nil {
+			return err
+		}
+		streams = append(streams, s)
+	}
+	err := CheckStreams(videos, streams)
+	if err != nil {
+		return err
+	}
+
+	mergeConfig := v.NewDefaultConfig()
+	mergeConfig.HWAccel = ""
+	mergeConfig.InArgs = append(mergeConfig.InArgs, []string{"-f", "concat", "-safe", "0", "-ignore_unknown"}...)
+	mergeConfig.OutArgs = append(mergeConfig.OutArgs, StreamMapArgs(streams[0])...)
+
+	// the concat demuxer resolves relative paths against the list file
+	absolute := []string{}
+	for _, video := range videos {
+		path, err := filepath.Abs(video)
+		if err != nil {
+			return err
+		}
+		absolute = append(absolute, path)
+	}
+	return v.merge(output, bar, mergeConfig, absolute...)
+}
+
+// Cut extracts duration seconds from input starting at start, keeping the GPMF track.
+// Without reencode the video is stream copied, so start should land on a keyframe.
+func (v *VMan) Cut(input, output string, start, duration float64, reencode bool, bar *mpb.Bar) error {
+	config := v.NewDefaultConfig()
+	if reencode {
+		config.VideoCodec = "libx264"
+		config.OutArgs = append(config.OutArgs, []string{"-crf", "18", "-preset", "fast"}...)
+	} else {
+		config.HWAccel = ""
+	}
+
+	err := v.trans.InitializeEmptyTranscoder()
+	if err != nil {
+		return err
+	}
+
+	config.InArgs = append(config.InArgs, config.hwAccelArgs()...)
+
+	ffprobe := utils.NewFFprobe(nil)
+	streams, err := ffprobe.Streams(input)
+	if err != nil {
+		return err
+	}
+	config.OutArgs = append(config.OutArgs, []string{"-map", "0:v:0", "-map", "0:a?"}...)
+	for _, stream := range streams.Streams {
+		if stream.CodecTagString == "gpmd" {
+			config.OutArgs = append(config.OutArgs, []string{"-map", fmt.Sprintf("0:%d", stream.Index), "-c:d", Copy, "-copy_unknown", "-tag:d", "gpmd"}...)
+			break
+		}
+	}
+
+	err = v.trans.SetInputPath(input)
+	if err != nil {
+		return err
+	}
+	err = v.trans.SetOutputPath(output)
+	if err != nil {
+		return err
+	}
+	v.trans.MediaFile().SetSeekTimeInput(strconv.FormatFloat(start, 'f', 3, 64))
+	v.trans.MediaFile().SetDuration(strconv.FormatFloat(duration, 'f', 3, 64))
+	v.trans.MediaFile().SetVideoCodec(config.VideoCodec)
+	v.trans.MediaFile().SetAudioCodec(config.AudioCodec)
+	v.trans.MediaFile().SetRawInputArgs(config.InArgs)
+	v.trans.MediaFile().SetRawOutputArgs(config.OutArgs)
+
+	done := v.trans.Run(true)
+
+	progress := v.trans.Output()
+
+	for msg := range progress {
+		s, _ := strconv.Atoi(msg.FramesProcessed)
+		bar.SetCurrent(int64(s))
+	}
+
+	return <-done
+}
+
+// RenderSequence encodes still frames into an H.264 video at framerate, capped at UHD width
+func (v *VMan) RenderSequence(output string, framerate int, bar *mpb.Bar, frames ...string) error {
+	config := v.NewDefaultConfig()
+	config.HWAccel = ""
+	config.VideoCodec = "libx264"
+	config.InArgs = append(config.InArgs, []string{"-f", "concat", "-safe", "0"}...)
+	config.OutArgs = append(config.OutArgs, []string{"-vf", "scale='min(3840,iw)':-2,format=yuv420p", "-r", strconv.Itoa(framerate), "-crf", "18", "-preset", "fast"}...)
+
+	err := v.trans.InitializeEmptyTranscoder()
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(output), "framelist.*.txt")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	duration := 1 / float64(framerate)
+	for _, frame := range frames {
+		_, err = fmt.Fprintf(file, "file '%s'\nduration %f\n", strings.ReplaceAll(frame, "'", `'\''`), duration)
+		if err != nil {
+			return err
+		}
+	}
+	// the concat demuxer ignores the duration of the last entry
+	_, err = fmt.Fprintf(file, "file '%s'\n", strings.ReplaceAll(frames[len(frames)-1], "'", `'\''`))
+	if err != nil {
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	err = v.trans.SetInputPath(file.Name())
+	if err != nil {
+		return err
+	}
+	err = v.trans.SetOutputPath(output)
+	if err != nil {
+		return err
+	}
+	v.trans.MediaFile().SetVideoCodec(config.VideoCodec)
+	v.trans.MediaFile().SetRawInputArgs(config.InArgs)
+	v.trans.MediaFile().SetRawOutputArgs(config.OutArgs)
+
+	done := v.trans.Run(true)
+
+	progress := v.trans.Output()
+
+	for msg := range progress {
+		s, _ := strconv.Atoi(msg.FramesProcessed)
+		bar.SetCurrent(int64(s))
+	}
+
+	return <-done
+}
+
 func (v *VMan) ExtractGPMF(input string) (*[]byte, error) {
 	err := v.trans.InitializeEmptyTranscoder()
 	if err != nil {
@@ -199,15 +401,17 @@ func (v *VMan) ExtractGPMF(input string) (*[]byte, error) {
 func (v *VMan) Convert(input, output string, resolution string, bitrate string, bar *mpb.Bar) error {
 	config := v.NewDefaultConfig()
 	config.VideoCodec = "libx264"
+	config.OutArgs = append(config.OutArgs, "-s", resolution, "-b:v", bitrate)
+	return v.convert(input, output, config, bar)
+}
 
+func (v *VMan) convert(input, output string, config FFConfig, bar *mpb.Bar) error {
 	err := v.trans.InitializeEmptyTranscoder()
 	if err != nil {
 		return err
 	}
 
-	if config.UseHWAccel {
-		config.InArgs = append(config.InArgs, []string{"-hwaccel", "cuda"}...)
-	}
+	config.InArgs = append(config.InArgs, config.hwAccelArgs()...)
 	err = v.trans.SetInputPath(input)
 	if err != nil {
 		return err
@@ -222,8 +426,6 @@ func (v *VMan) Convert(input, output string, resolution string, bitrate string,
 	v.trans.MediaFile().SetAudioCodec(config.AudioCodec)
 	v.trans.MediaFile().SetRawInputArgs(config.InArgs)
 	v.trans.MediaFile().SetRawOutputArgs(config.OutArgs)
-	v.trans.MediaFile().SetVideoBitRate(bitrate)
-	v.trans.MediaFile().SetResolution(resolution)
 
 	done := v.trans.Run(true)
 
@@ -0,0 +1,67 @@
+package videomanipulation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/konradit/mmt/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamMapArgs(t *testing.T) {
+	// ffprobe -show_streams of a HERO9 Black video, trimmed
+	payload := `{"streams":[
+		{"index":0,"codec_name":"hevc","codec_type":"video","codec_tag_string":"hvc1"},
+		{"index":1,"codec_name":"aac","codec_type":"audio","codec_tag_string":"mp4a"},
+		{"index":2,"codec_type":"data","codec_tag_string":"tmcd"},
+		{"index":3,"codec_name":"bin_data","codec_type":"data","codec_tag_string":"gpmd"},
+		{"index":4,"codec_type":"data","codec_tag_string":"fdsc"}
+	]}`
+	streams := utils.StreamsResponse{}
+	require.NoError(t, json.Unmarshal([]byte(payload), &streams))
+
+	require.Equal(t, []string{
+		"-map", "0:0",
+		"-map", "0:1",
+		"-map", "0:3", "-tag:2", "gpmd",
+		"-c:d", "copy", "-copy_unknown", "-write_tmcd", "on",
+	}, StreamMapArgs(&streams))
+}
+
+func TestCheckStreams(t *testing.T) {
+	parse := func(payload string) *utils.StreamsResponse {
+		streams := utils.StreamsResponse{}
+		require.NoError(t, json.Unmarshal([]byte(payload), &streams))
+		return &streams
+	}
+	hero9 := `{"streams":[
+		{"index":0,"codec_name":"hevc","codec_type":"video","width":3840,"height":2160,"r_frame_rate":"30000/1001"},
+		{"index":1,"codec_name":"aac","codec_type":"audio","sample_rate":"48000","channels":2},
+		{"index":2,"codec_name":"bin_data","codec_type":"data","codec_tag_string":"gpmd"}
+	]}`
+	videos := []string{"GX011273.MP4", "GX021273.MP4"}
+
+	require.NoError(t, CheckStreams(videos, []*utils.StreamsResponse{parse(hero9), parse(hero9)}))
+
+	for _, other := range []string{
+		// no GPMF track
+		`{"streams":[
+			{"index":0,"codec_name":"hevc","codec_type":"video","width":3840,"height":2160,"r_frame_rate":"30000/1001"},
+			{"index":1,"codec_name":"aac","codec_type":"audio","sample_rate":"48000","channels":2}
+		]}`,
+		// 60fps
+		`{"streams":[
+			{"index":0,"codec_name":"hevc","codec_type":"video","width":3840,"height":2160,"r_frame_rate":"60000/1001"},
+			{"index":1,"codec_name":"aac","codec_type":"audio","sample_rate":"48000","channels":2},
+			{"index":2,"codec_name":"bin_data","codec_type":"data","codec_tag_string":"gpmd"}
+		]}`,
+		// H.264 at 2.7K
+		`{"streams":[
+			{"index":0,"codec_name":"h264","codec_type":"video","width":2704,"height":1520,"r_frame_rate":"30000/1001"},
+			{"index":1,"codec_name":"aac","codec_type":"audio","sample_rate":"48000","channels":2},
+			{"index":2,"codec_name":"bin_data","codec_type":"data","codec_tag_string":"gpmd"}
+		]}`,
+	} {
+		require.Error(t, CheckStreams(videos, []*utils.StreamsResponse{parse(hero9), parse(other)}))
+	}
+}
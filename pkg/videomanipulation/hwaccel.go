@@ -0,0 +1,100 @@
+package videomanipulation
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Hardware acceleration settings, anything else is passed to -hwaccel as is
+const (
+	HWAccelAuto = "auto"
+	HWAccelNone = "none"
+)
+
+// accelerators tried in order when set to auto
+var hwAccelPreference = []string{"vaapi", "qsv", "videotoolbox", "cuda"}
+
+var (
+	hwAccelMu       sync.Mutex
+	hwAccelSetting  = HWAccelAuto
+	hwAccelDetected string
+	hwAccelOnce     sync.Once
+)
+
+// SetHWAccel picks the accelerator used by every VMan created afterwards: auto, none or an ffmpeg -hwaccel name
+func SetHWAccel(name string) {
+	hwAccelMu.Lock()
+	defer hwAccelMu.Unlock()
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = HWAccelAuto
+	}
+	hwAccelSetting = name
+}
+
+// resolveHWAccel turns the current setting into an -hwaccel value, empty for software decoding
+func resolveHWAccel(ffmpegBin string) string {
+	hwAccelMu.Lock()
+	setting := hwAccelSetting
+	hwAccelMu.Unlock()
+
+	switch setting {
+	case HWAccelNone, "off", "software":
+		return ""
+	case HWAccelAuto:
+		hwAccelOnce.Do(func() {
+			hwAccelDetected = pickHWAccel(listHWAccels(ffmpegBin), func(name string) bool {
+				return canInitHWDevice(ffmpegBin, name)
+			})
+		})
+		return hwAccelDetected
+	}
+	return setting
+}
+
+// parseHWAccels reads the output of ffmpeg -hwaccels
+func parseHWAccels(output string) []string {
+	accels := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasSuffix(line, ":") {
+			continue
+		}
+		accels = append(accels, line)
+	}
+	return accels
+}
+
+// pickHWAccel returns the first preferred accelerator ffmpeg was built with and that works on this machine
+func pickHWAccel(available []string, usable func(string) bool) string {
+	for _, name := range hwAccelPreference {
+		for _, accel := range available {
+			if accel == name && usable(name) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func listHWAccels(ffmpegBin string) []string {
+	if ffmpegBin == "" {
+		return nil
+	}
+	cmd := exec.Command(ffmpegBin, "-hide_banner", "-hwaccels") // #nosec
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil
+	}
+	return parseHWAccels(out.String())
+}
+
+// canInitHWDevice checks the accelerator has a device behind it, -hwaccels lists everything ffmpeg was built with
+func canInitHWDevice(ffmpegBin, name string) bool {
+	cmd := exec.Command(ffmpegBin, "-hide_banner", "-loglevel", "error", "-init_hw_device", name,
+		"-f", "lavfi", "-i", "nullsrc=s=16x16", "-frames:v", "1", "-f", "null", "-") // #nosec
+	return cmd.Run() == nil
+}
@@ -0,0 +1,35 @@
+package videomanipulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHWAccels(t *testing.T) {
+	output := "Hardware acceleration methods:\nvdpau\ncuda\nvaapi\nqsv\ndrm\nopencl\nvulkan\n\n"
+	require.Equal(t, []string{"vdpau", "cuda", "vaapi", "qsv", "drm", "opencl", "vulkan"}, parseHWAccels(output))
+	require.Empty(t, parseHWAccels("Hardware acceleration methods:\n\n"))
+}
+
+func TestPickHWAccel(t *testing.T) {
+	available := []string{"vdpau", "cuda", "vaapi", "qsv"}
+	all := func(string) bool { return true }
+	require.Equal(t, "vaapi", pickHWAccel(available, all))
+
+	// no render node, only the NVIDIA card works
+	onlyCuda := func(name string) bool { return name == "cuda" }
+	require.Equal(t, "cuda", pickHWAccel(available, onlyCuda))
+
+	require.Equal(t, "", pickHWAccel(available, func(string) bool { return false }))
+	require.Equal(t, "", pickHWAccel([]string{"vdpau"}, all))
+}
+
+func TestResolveHWAccel(t *testing.T) {
+	defer SetHWAccel(HWAccelAuto)
+
+	SetHWAccel("none")
+	require.Equal(t, "", resolveHWAccel("ffmpeg"))
+	SetHWAccel(" CUDA ")
+	require.Equal(t, "cuda", resolveHWAccel("ffmpeg"))
+}
@@ -0,0 +1,76 @@
+package videomanipulation
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+	"github.com/vbauerster/mpb/v8"
+)
+
+// OutputCodec is a video codec a graded video can be encoded with
+type OutputCodec struct {
+	VideoCodec string
+	AudioCodec string
+	Ext        string
+	Args       []string
+}
+
+var OutputCodecs = map[string]OutputCodec{
+	"h264": {
+		VideoCodec: "libx264",
+		AudioCodec: "aac",
+		Ext:        ".MP4",
+		Args:       []string{"-crf", "18", "-preset", "fast", "-pix_fmt", "yuv420p"},
+	},
+	"h265": {
+		VideoCodec: "libx265",
+		AudioCodec: "aac",
+		Ext:        ".MP4",
+		Args:       []string{"-crf", "20", "-preset", "fast", "-pix_fmt", "yuv420p10le", "-tag:v", "hvc1"},
+	},
+	"prores": {
+		VideoCodec: "prores_ks",
+		AudioCodec: "pcm_s16le",
+		Ext:        ".MOV",
+		Args:       []string{"-profile:v", "3", "-pix_fmt", "yuv422p10le"},
+	},
+}
+
+func GetOutputCodec(name string) (OutputCodec, error) {
+	codec, ok := OutputCodecs[strings.ToLower(name)]
+	if !ok {
+		return OutputCodec{}, mErrors.ErrInvalidSuppliedData(name)
+	}
+	return codec, nil
+}
+
+// escapeFilterValue quotes a filter option for the option parser, then again for the filtergraph parser
+func escapeFilterValue(value string) string {
+	value = filepath.ToSlash(value)
+	option := strings.NewReplacer(`'`, `\'`, `:`, `\:`).Replace(value)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`).Replace(option)
+}
+
+// lutFilter grades with lut3d, below full intensity the graded frame is blended over the original
+func lutFilter(lut string, intensity float64) string {
+	graded := "lut3d=file=" + escapeFilterValue(lut)
+	if intensity >= 1 {
+		return graded
+	}
+	return fmt.Sprintf("split[original][grade];[grade]%s[graded];[graded][original]blend=all_mode=normal:all_opacity=%.2f", graded, intensity)
+}
+
+// ApplyLUT grades input with a 3D LUT (.cube, .3dl...) at intensity between 0 and 1, audio is encoded to suit the container
+func (v *VMan) ApplyLUT(input, output, lut string, intensity float64, codec OutputCodec, bar *mpb.Bar) error {
+	if intensity <= 0 || intensity > 1 {
+		return mErrors.ErrInvalidSuppliedData(intensity)
+	}
+	config := v.NewDefaultConfig()
+	config.VideoCodec = codec.VideoCodec
+	config.AudioCodec = codec.AudioCodec
+	config.OutArgs = append(config.OutArgs, "-map", "0:v:0", "-map", "0:a?", "-vf", lutFilter(lut, intensity))
+	config.OutArgs = append(config.OutArgs, codec.Args...)
+	return v.convert(input, output, config, bar)
+}
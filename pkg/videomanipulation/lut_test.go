@@ -0,0 +1,27 @@
+package videomanipulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLUTFilter(t *testing.T) {
+	require.Equal(t, "lut3d=file=/luts/Protune Flat.cube", lutFilter("/luts/Protune Flat.cube", 1))
+	require.Equal(t,
+		"split[original][grade];[grade]lut3d=file=/luts/dlog.cube[graded];[graded][original]blend=all_mode=normal:all_opacity=0.75",
+		lutFilter("/luts/dlog.cube", 0.75))
+}
+
+func TestEscapeFilterValue(t *testing.T) {
+	require.Equal(t, `C\\:/LUTs/D-Log\\\'s \[v2\].cube`, escapeFilterValue(`C:/LUTs/D-Log's [v2].cube`))
+}
+
+func TestOutputCodecAudio(t *testing.T) {
+	// PCM from a .MOV can't be copied into an .MP4
+	for name, audio := range map[string]string{"h264": "aac", "h265": "aac", "prores": "pcm_s16le"} {
+		codec, err := GetOutputCodec(name)
+		require.NoError(t, err)
+		require.Equal(t, audio, codec.AudioCodec, name)
+	}
+}
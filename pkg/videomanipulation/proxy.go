@@ -0,0 +1,143 @@
+package videomanipulation
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	mErrors "github.com/konradit/mmt/pkg/errors"
+	"github.com/vbauerster/mpb/v8"
+)
+
+// ProxyPreset describes an editing proxy, Height and Bitrate can be overridden per run
+type ProxyPreset struct {
+	VideoCodec string
+	AudioCodec string
+	Height     int
+	Bitrate    string // only used by codecs without a fixed profile bitrate
+	Ext        string
+	Args       []string
+}
+
+var ProxyPresets = map[string]ProxyPreset{
+	"h264": {
+		VideoCodec: "libx264",
+		AudioCodec: "aac",
+		Height:     720,
+		Bitrate:    "5M",
+		Ext:        ".MP4",
+		Args:       []string{"-preset", "fast", "-pix_fmt", "yuv420p", "-movflags", "+faststart"},
+	},
+	"prores": {
+		VideoCodec: "prores_ks",
+		AudioCodec: "pcm_s16le",
+		Height:     720,
+		Ext:        ".MOV",
+		Args:       []string{"-profile:v", "0", "-pix_fmt", "yuv422p10le"},
+	},
+	"dnxhr": {
+		VideoCodec: "dnxhd",
+		AudioCodec: "pcm_s16le",
+		Height:     1080,
+		Ext:        ".MOV",
+		Args:       []string{"-profile:v", "dnxhr_lb", "-pix_fmt", "yuv422p"},
+	},
+}
+
+func GetProxyPreset(name string) (ProxyPreset, error) {
+	preset, ok := ProxyPresets[strings.ToLower(name)]
+	if !ok {
+		return ProxyPreset{}, mErrors.ErrInvalidSuppliedData(name)
+	}
+	return preset, nil
+}
+
+// proxyExtensions are the videos proxies are made for
+var proxyExtensions = []string{".mp4", ".mov", ".insv"}
+
+// IsProxyCandidate is true for videos that aren't proxies themselves
+func IsProxyCandidate(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, "LRV_") || strings.HasPrefix(name, "PRO_LRV_") {
+		return false
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if dir == "proxy" {
+			return false
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, candidate := range proxyExtensions {
+		if ext == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ProxyPath follows the layout GoPro LRVs are imported to, the folder a video sits in is kept under videos/proxy:
+day/videos/1920x1080 59.94/GX1273-01.MP4 -> day/videos/proxy/1920x1080 59.94/GX1273-01.MP4
+day/videos/DJI_0001.MP4 -> day/videos/proxy/DJI_0001.MP4
+Videos outside of a videos folder get a proxy folder next to them.
+*/
+func ProxyPath(video, ext string) string {
+	dir := filepath.Dir(video)
+	name := strings.TrimSuffix(filepath.Base(video), filepath.Ext(video)) + ext
+
+	parent := filepath.Base(dir)
+	if parent == "videos" {
+		return filepath.Join(dir, "proxy", name)
+	}
+	for ancestor := filepath.Dir(dir); ancestor != filepath.Dir(ancestor); ancestor = filepath.Dir(ancestor) {
+		if filepath.Base(ancestor) == "videos" {
+			return filepath.Join(ancestor, "proxy", parent, name)
+		}
+	}
+	return filepath.Join(dir, "proxy", name)
+}
+
+// HasProxy looks for a proxy made by mmt or copied from the camera: a GoPro LRV under videos/proxy or an Insta360 LRV next to the video
+func HasProxy(video string, preset ProxyPreset) bool {
+	for _, ext := range []string{preset.Ext, ".MP4"} {
+		if _, err := os.Stat(ProxyPath(video, ext)); err == nil {
+			return true
+		}
+	}
+
+	// VID_20221012_102725_10_586.insv -> LRV_20221012_102725_01_586.insv
+	parts := strings.Split(strings.TrimPrefix(filepath.Base(video), "PRO_"), "_")
+	if len(parts) < 3 || parts[0] != "VID" {
+		return false
+	}
+	for _, prefix := range []string{"LRV_", "PRO_LRV_"} {
+		matches, _ := filepath.Glob(filepath.Join(filepath.Dir(video), prefix+parts[1]+"_"+parts[2]+"_*"))
+		if len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Proxy encodes a low resolution copy of input for editing, output is removed if encoding fails
+func (v *VMan) Proxy(input, output string, preset ProxyPreset, bar *mpb.Bar) error {
+	config := v.NewDefaultConfig()
+	config.VideoCodec = preset.VideoCodec
+	config.AudioCodec = preset.AudioCodec
+	config.OutArgs = append(config.OutArgs, "-map", "0:v:0", "-map", "0:a?", "-vf", "scale=-2:"+strconv.Itoa(preset.Height))
+	if preset.Bitrate != "" {
+		config.OutArgs = append(config.OutArgs, "-b:v", preset.Bitrate)
+	}
+	config.OutArgs = append(config.OutArgs, preset.Args...)
+
+	err := os.MkdirAll(filepath.Dir(output), 0o755)
+	if err != nil {
+		return err
+	}
+	err = v.convert(input, output, config, bar)
+	if err != nil {
+		os.Remove(output)
+	}
+	return err
+}
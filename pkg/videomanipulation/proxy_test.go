@@ -0,0 +1,50 @@
+package videomanipulation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyPath(t *testing.T) {
+	day := filepath.Join("out", "19-10-2022")
+	require.Equal(t, filepath.Join(day, "videos", "proxy", "1920x1080 59.94", "GX1273-01.MP4"),
+		ProxyPath(filepath.Join(day, "videos", "1920x1080 59.94", "GX1273-01.MP4"), ".MP4"))
+	require.Equal(t, filepath.Join(day, "videos", "proxy", "1920x1080 59.94", "GX1273-01.MOV"),
+		ProxyPath(filepath.Join(day, "videos", "good stuff", "1920x1080 59.94", "GX1273-01.MP4"), ".MOV"))
+	require.Equal(t, filepath.Join(day, "videos", "proxy", "DJI_0001.MP4"),
+		ProxyPath(filepath.Join(day, "videos", "DJI_0001.MP4"), ".MP4"))
+	require.Equal(t, filepath.Join("clips", "proxy", "C0001.MOV"),
+		ProxyPath(filepath.Join("clips", "C0001.MP4"), ".MOV"))
+}
+
+func TestIsProxyCandidate(t *testing.T) {
+	require.True(t, IsProxyCandidate(filepath.Join("videos", "360", "102725", "VID_20221012_102725_00_586.insv")))
+	require.True(t, IsProxyCandidate(filepath.Join("videos", "DJI_0001.mov")))
+	require.False(t, IsProxyCandidate(filepath.Join("videos", "360", "102725", "LRV_20221012_102725_01_586.insv")))
+	require.False(t, IsProxyCandidate(filepath.Join("videos", "proxy", "1920x1080 59.94", "GX1273-01.MP4")))
+	require.False(t, IsProxyCandidate(filepath.Join("photos", "GOPR0002.JPG")))
+}
+
+func TestHasProxy(t *testing.T) {
+	videos := filepath.Join(t.TempDir(), "videos")
+	touch := func(path string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte{}, 0o600))
+	}
+	preset := ProxyPresets["prores"]
+
+	gopro := filepath.Join(videos, "1920x1080 59.94", "GX1273-01.MP4")
+	touch(gopro)
+	require.False(t, HasProxy(gopro, preset))
+	touch(filepath.Join(videos, "proxy", "1920x1080 59.94", "GX1273-01.MP4"))
+	require.True(t, HasProxy(gopro, preset))
+
+	insta360 := filepath.Join(videos, "360", "102725", "VID_20221012_102725_00_586.insv")
+	touch(insta360)
+	require.False(t, HasProxy(insta360, preset))
+	touch(filepath.Join(videos, "360", "102725", "LRV_20221012_102725_01_586.insv"))
+	require.True(t, HasProxy(insta360, preset))
+}